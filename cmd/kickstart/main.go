@@ -1,11 +1,15 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kickstartdev/kickstart/internal/auth"
 	"github.com/kickstartdev/kickstart/internal/debug"
+	"github.com/kickstartdev/kickstart/internal/scaffold"
 	"github.com/kickstartdev/kickstart/ui"
 )
 
@@ -13,7 +17,78 @@ func main() {
 	debug.Init("debug.log")
 	debug.Log("starting kickstart")
 
-	p := tea.NewProgram(ui.NewApp(), tea.WithAltScreen())
+	dryRun := flag.Bool("dry-run", false, "render the project locally without creating a repository")
+	local := flag.Bool("local", false, "create a local git repository instead of a GitHub one")
+	remote := flag.String("remote", "", "with -local, add this URL as the origin remote")
+	into := flag.String("into", "", "add the project to this existing owner/repo through a pull request")
+	intoPath := flag.String("path", "", "with -into, the directory to add the project in (default: the project name)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: kickstart [flags] [resume | update | <template dir>]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *remote != "" && !*local {
+		fmt.Println("-remote can only be used with -local")
+		os.Exit(2)
+	}
+	if *intoPath != "" && *into == "" {
+		fmt.Println("-path can only be used with -into")
+		os.Exit(2)
+	}
+	if *into != "" {
+		if *local {
+			fmt.Println("-into can't be used with -local")
+			os.Exit(2)
+		}
+		if owner, repo, ok := strings.Cut(*into, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			fmt.Println("-into must be owner/repo")
+			os.Exit(2)
+		}
+	}
+
+	opts := ui.Options{
+		DryRun:   *dryRun,
+		Local:    *local,
+		Remote:   *remote,
+		Into:     *into,
+		IntoPath: *intoPath,
+	}
+	switch arg := flag.Arg(0); arg {
+	case "":
+	case "update":
+		os.Exit(runUpdate(flag.Args()[1:]))
+	case "resume":
+		state, err := scaffold.LoadState()
+		if os.IsNotExist(err) {
+			fmt.Println("nothing to resume: no unfinished scaffold found")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("can't resume: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := auth.LoadConfig(); err != nil {
+			fmt.Println("not logged in, run kickstart first")
+			os.Exit(1)
+		}
+		opts.Resume = state
+	default:
+		// anything else is a local template directory or file:// URL, which
+		// may extend or include GitHub templates
+		var token string
+		if cfg, err := auth.LoadConfig(); err == nil {
+			token = cfg.Token
+		}
+		tmpl, err := scaffold.LocalTemplate(token, arg)
+		if err != nil {
+			fmt.Printf("can't use template %s: %v\n", arg, err)
+			os.Exit(1)
+		}
+		opts.Template = &tmpl
+	}
+
+	p := tea.NewProgram(ui.NewApp(opts), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("something went wrong: %v", err)
 		os.Exit(1)
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/kickstartdev/kickstart/internal/auth"
+	"github.com/kickstartdev/kickstart/internal/scaffold"
+)
+
+// runUpdate implements `kickstart update [-ref <ref>] [project dir]` and
+// returns the exit code.
+func runUpdate(args []string) int {
+	flags := flag.NewFlagSet("update", flag.ExitOnError)
+	ref := flags.String("ref", "", "template branch, tag or commit to update to (default: the ref the project was generated from)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: kickstart update [flags] [project dir]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	cfg, err := auth.LoadConfig()
+	if err != nil {
+		fmt.Println("not logged in, run kickstart first")
+		return 1
+	}
+
+	u, err := scaffold.NewUpdater(cfg.Token, dir, *ref)
+	if err != nil {
+		fmt.Printf("can't update: %v\n", err)
+		return 1
+	}
+	defer u.Cleanup()
+
+	for _, step := range u.Steps() {
+		fmt.Println(step.Name + "...")
+		if err := step.Fn(); errors.Is(err, scaffold.ErrUpToDate) {
+			fmt.Println("already up to date")
+			return 0
+		} else if err != nil {
+			fmt.Printf("%s failed: %v\n", step.Name, err)
+			return 1
+		}
+	}
+
+	fmt.Printf("\nupdate committed on branch %s\n", u.Branch)
+	if len(u.Conflicts) > 0 {
+		fmt.Println("resolve the conflicts in:")
+		for _, path := range u.Conflicts {
+			fmt.Println("  " + path)
+		}
+	}
+	if u.PullRequest != "" {
+		fmt.Println("pull request: " + u.PullRequest)
+	} else {
+		fmt.Println("origin is not on GitHub, push the branch and open a pull request yourself")
+	}
+	return 0
+}
@@ -52,7 +52,7 @@ func RequestDeviceCode() (*DeviceCodeResponse, error) {
 
 	body, _ := json.Marshal(map[string]string{
 		"client_id": clientID,
-		"scope":     "repo read:org workflow",
+		"scope":     "repo read:org workflow delete_repo",
 	})
 
 	req, _ := http.NewRequest("POST", "https://github.com/login/device/code", bytes.NewBuffer(body))
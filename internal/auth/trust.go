@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Trusted hooks are stored per template source with a hash of the commands,
+// so a template that changes its hooks has to be trusted again.
+func trustPath() string {
+	return filepath.Join(filepath.Dir(configPath()), "trusted.json")
+}
+
+func hookHash(commands []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(commands, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func loadTrusted() map[string]string {
+	trusted := make(map[string]string)
+	data, err := os.ReadFile(trustPath())
+	if err != nil {
+		return trusted
+	}
+	json.Unmarshal(data, &trusted)
+	return trusted
+}
+
+// IsTrusted reports whether the user already agreed to run these hook
+// commands from source (e.g. "owner/repo").
+func IsTrusted(source string, commands []string) bool {
+	return loadTrusted()[source] == hookHash(commands)
+}
+
+// Trust remembers that the user agreed to run these hook commands from source.
+func Trust(source string, commands []string) error {
+	trusted := loadTrusted()
+	trusted[source] = hookHash(commands)
+
+	if err := os.MkdirAll(filepath.Dir(trustPath()), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(trusted)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(trustPath(), data, 0600)
+}
@@ -0,0 +1,189 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ref is a version of a template: a tag or a branch, and the commit it
+// points at.
+type Ref struct {
+	Name string
+	// Kind is "tag" or "branch"
+	Kind string
+	SHA  string
+	// Default marks the repository's default branch
+	Default bool
+}
+
+// ListRefs lists the versions of a template repository: the default
+// branch first, then tags newest first by semantic version, then the other
+// branches.
+func ListRefs(token string, owner string, repo string) ([]Ref, error) {
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := getJSON(token, fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo), &info); err != nil {
+		return nil, err
+	}
+
+	tags, err := listRefs(token, fmt.Sprintf("https://api.github.com/repos/%s/%s/tags", owner, repo), "tag")
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return compareVersions(tags[i].Name, tags[j].Name) > 0
+	})
+
+	branches, err := listRefs(token, fmt.Sprintf("https://api.github.com/repos/%s/%s/branches", owner, repo), "branch")
+	if err != nil {
+		return nil, err
+	}
+
+	var refs, others []Ref
+	for _, b := range branches {
+		if b.Name == info.DefaultBranch {
+			b.Default = true
+			refs = append(refs, b)
+		} else {
+			others = append(others, b)
+		}
+	}
+	refs = append(refs, tags...)
+	return append(refs, others...), nil
+}
+
+func listRefs(token string, url string, kind string) ([]Ref, error) {
+	var refs []Ref
+	for page := 1; ; page++ {
+		var items []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				SHA string `json:"sha"`
+			} `json:"commit"`
+		}
+		if err := getJSON(token, fmt.Sprintf("%s?per_page=100&page=%d", url, page), &items); err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			refs = append(refs, Ref{Name: item.Name, Kind: kind, SHA: item.Commit.SHA})
+		}
+		if len(items) < 100 {
+			return refs, nil
+		}
+	}
+}
+
+func getJSON(token string, url string, v any) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub API returned %d for %s", resp.StatusCode, req.URL.Path)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// compareVersions orders tags by semantic version, e.g. v1.10.0 after
+// v1.9.2 and v2.0.0-rc.1 before v2.0.0. Tags that aren't versions sort
+// after all versions, by name.
+func compareVersions(a string, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(b, a)
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for i := range va.parts {
+		if va.parts[i] != vb.parts[i] {
+			if va.parts[i] < vb.parts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// a pre-release comes before its release
+	switch {
+	case va.pre == vb.pre:
+		return 0
+	case va.pre == "":
+		return 1
+	case vb.pre == "":
+		return -1
+	}
+	return comparePrerelease(va.pre, vb.pre)
+}
+
+type version struct {
+	parts [3]int
+	pre   string
+}
+
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, _ := strings.Cut(s, "-")
+
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 {
+		return version{}, false
+	}
+	v := version{pre: pre}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v.parts[i] = n
+	}
+	return v, true
+}
+
+// comparePrerelease compares dot-separated identifiers, numerically where
+// both are numbers, as semver specifies.
+func comparePrerelease(a string, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		na, errA := strconv.Atoi(as[i])
+		nb, errB := strconv.Atoi(bs[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,63 @@
+package github
+
+import (
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"1.0.0", "v1.0.0", 0},
+		{"v1.10.0", "v1.9.2", 1},
+		{"v1.9.2", "v1.10.0", -1},
+		{"v2", "v1.99.99", 1},
+		{"v1.2", "v1.2.0", 0},
+		{"v2.0.0-rc.1", "v2.0.0", -1},
+		{"v2.0.0", "v2.0.0-rc.1", 1},
+		{"v2.0.0-rc.2", "v2.0.0-rc.10", -1},
+		{"v2.0.0-alpha", "v2.0.0-beta", -1},
+		{"v2.0.0-alpha", "v2.0.0-alpha.1", -1},
+		{"v2.0.0-1", "v2.0.0-alpha", -1},
+		{"v1.0.0+build.5", "v1.0.0", 0},
+		{"v1.0.0", "latest", 1},
+		{"latest", "v1.0.0", -1},
+		{"v1.2.3.4", "v0.0.1", -1},
+		{"alpha", "beta", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := sign(compareVersions(tt.a, tt.b)); got != tt.want {
+				t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortTags(t *testing.T) {
+	tags := []string{"v1.9.2", "nightly", "v2.0.0-rc.1", "v1.10.0", "v2.0.0", "v0.1", "beta", "v2.0.0-rc.10"}
+	want := []string{"v2.0.0", "v2.0.0-rc.10", "v2.0.0-rc.1", "v1.10.0", "v1.9.2", "v0.1", "beta", "nightly"}
+
+	// the order ListRefs lists tags in
+	sort.SliceStable(tags, func(i, j int) bool {
+		return compareVersions(tags[i], tags[j]) > 0
+	})
+	if !slices.Equal(tags, want) {
+		t.Errorf("sorted tags = %q, want %q", tags, want)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -16,6 +16,92 @@ type TemplateConfig struct {
 	Description		string		`yaml:"description"`
 	Branch			string		`yaml:"branch"`
 	Variables		[]Variable	`yaml:"variables"`
+
+	// Extends is a template this one builds on, "owner/repo[@ref]" or a
+	// path relative to a local template. Its variables, rules and files
+	// come first and this template's override them.
+	Extends			string		`yaml:"extends"`
+	// Include overlays skeleton fragments, "[<template>:]<dir>", after the
+	// extended template's files and before this template's skeleton/
+	Include			[]string	`yaml:"include"`
+
+	// Render limits rendering to matching files, empty renders everything
+	Render			[]string	`yaml:"render"`
+	// CopyOnly files are copied verbatim, even if Render matches them
+	CopyOnly		[]string	`yaml:"copy_only"`
+	// TmplSuffix renders only files ending in .tmpl and strips the suffix
+	TmplSuffix		bool		`yaml:"tmpl_suffix"`
+
+	// Delimiters replaces the default {{ }} delimiters, e.g. ["[[", "]]"]
+	Delimiters		[]string	`yaml:"delimiters"`
+	// DelimiterOverrides sets delimiters for the paths and contents of
+	// files matching a glob
+	DelimiterOverrides	[]DelimiterRule	`yaml:"delimiter_overrides"`
+
+	// Exclude drops skeleton paths, optionally only when a condition holds
+	Exclude			[]ExcludeRule	`yaml:"exclude"`
+	// Ignore lists gitignore-style patterns that never reach the new
+	// project, on top of the skeleton's .kickstartignore
+	Ignore			[]string	`yaml:"ignore"`
+
+	Hooks			Hooks		`yaml:"hooks"`
+
+	// Variants are flavours of the template, picked before the variables
+	Variants		[]Variant	`yaml:"variants"`
+
+	// Repository holds the defaults for the repository created for a
+	// project, which the user can change in the form
+	Repository		RepoConfig	`yaml:"repository"`
+
+	// Commit configures the commit the project is created with
+	Commit			CommitConfig	`yaml:"commit"`
+}
+
+type CommitConfig struct {
+	// Message is a template for the commit message, rendered with the
+	// variables plus {{.template}}, the template's name
+	Message			string		`yaml:"message"`
+}
+
+type RepoConfig struct {
+	// Owner is the organization to create the repository in, empty for
+	// the user's own account
+	Owner			string		`yaml:"owner"`
+	// Visibility is "private" (default), "internal" or "public"
+	Visibility		string		`yaml:"visibility"`
+	Description		string		`yaml:"description"`
+	Homepage		string		`yaml:"homepage"`
+	Topics			[]string	`yaml:"topics"`
+	// DefaultBranch is the branch the project is committed on, "main"
+	// unless set
+	DefaultBranch	string		`yaml:"default_branch"`
+}
+
+// Variant is a named flavour of a template. Its files come either from
+// Skeleton, used instead of skeleton/, or from Overlay, copied over
+// skeleton/.
+type Variant struct {
+	Name			string		`yaml:"name"`
+	Description		string		`yaml:"description"`
+	Skeleton		string		`yaml:"skeleton"`
+	Overlay			string		`yaml:"overlay"`
+}
+
+type Hooks struct {
+	// PostCreate commands run in the new project once it is cloned
+	PostCreate		[]string	`yaml:"post_create"`
+}
+
+type DelimiterRule struct {
+	Paths			[]string	`yaml:"paths"`
+	Delimiters		[]string	`yaml:"delimiters"`
+}
+
+type ExcludeRule struct {
+	Paths			[]string	`yaml:"paths"`
+	// When is a template expression over the variables, e.g.
+	// "not include_helm" or `eq language "go"`. Empty always excludes.
+	When			string		`yaml:"when"`
 }
 
 type Variable struct {
@@ -23,12 +109,31 @@ type Variable struct {
 	Description		string		`yaml:"description"`
 	Default			string		`yaml:"default"`
 	Required		bool		`yaml:"required"`
+	// Type is "string" (default), "bool", "list" (comma separated),
+	// "secret", a string that is masked and never recorded, or "choice",
+	// one of Choices
+	Type			string		`yaml:"type"`
+	Choices			[]string	`yaml:"choices"`
 }
 
+const (
+	TypeSecret = "secret"
+	TypeChoice = "choice"
+)
+
 type Template struct {
 	Config		TemplateConfig
 	Owner		string
 	Repo		string
+	// Path is set for templates read from a local directory
+	Path		string
+	// Ref is the version picked, a tag or branch; empty uses Config.Branch
+	Ref		string
+	// Revision pins the template to the commit Ref pointed at when picked
+	Revision	string
+	// Bases pins the templates it extends or includes, by reference
+	// ("owner/repo@ref"), to the commits its config was read from
+	Bases		map[string]string
 }
 
 func ListTemplates(token string, username string) ([]Template, error) {
@@ -43,7 +148,7 @@ func ListTemplates(token string, username string) ([]Template, error) {
 	var templates []Template
 	for _, repo := range repos {
 		debug.Log("ListTemplates: checking %s/%s for template.yaml", repo.Owner, repo.Name)
-		cfg, err := GetTemplateConfig(token, repo.Owner, repo.Name)
+		cfg, err := GetTemplateConfig(token, repo.Owner, repo.Name, "")
 		if err != nil {
 			debug.Log("ListTemplates: no template in %s/%s: %v", repo.Owner, repo.Name, err)
 			continue
@@ -120,8 +225,14 @@ func listUserRepos(token string) ([]repoInfo, error) {
 }
 
 
-func GetTemplateConfig(token string, owner string, repo string) (*TemplateConfig, error) {
-	req, err := http.NewRequest("GET",fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/template.yaml", owner, repo), nil )
+// GetTemplateConfig reads template.yaml at ref, a branch, tag or commit.
+// An empty ref reads the default branch.
+func GetTemplateConfig(token string, owner string, repo string, ref string) (*TemplateConfig, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/template.yaml", owner, repo)
+	if ref != "" {
+		url += "?ref=" + ref
+	}
+	req, err := http.NewRequest("GET", url, nil )
 
 	if err != nil {
 		return nil, err
@@ -146,9 +257,14 @@ func GetTemplateConfig(token string, owner string, repo string) (*TemplateConfig
 		return nil, err
 	}
 
+	return ParseTemplateConfig(body, owner+"/"+repo)
+}
+
+// ParseTemplateConfig parses a template.yaml read from source.
+func ParseTemplateConfig(data []byte, source string) (*TemplateConfig, error) {
 	var cfg TemplateConfig
-	if err := yaml.Unmarshal(body, &cfg); err != nil {
-		return nil, fmt.Errorf("invalid template.yaml in %s/%s: %v", owner, repo, err)
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid template.yaml in %s: %v", source, err)
 	}
 
 	return &cfg, nil
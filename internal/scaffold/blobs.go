@@ -0,0 +1,132 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// Small text files are sent inline as tree entry content, which creates
+// their blobs as part of the single create-tree request. Only binaries and
+// large files are uploaded as separate blobs.
+const (
+	inlineMaxFile  = 64 << 10
+	inlineMaxTotal = 8 << 20
+)
+
+// treeEntry is an entry of a create-tree request, with either the SHA of
+// an uploaded blob or the file's content inline.
+type treeEntry struct {
+	Path    string  `json:"path"`
+	Mode    string  `json:"mode"`
+	Type    string  `json:"type"`
+	SHA     *string `json:"sha,omitempty"`
+	Content *string `json:"content,omitempty"`
+}
+
+func inlinable(f fileEntry) bool {
+	if len(f.Data) > inlineMaxFile {
+		return false
+	}
+	// inline content is sent as a JSON string, so it has to be text
+	return utf8.Valid(f.Data) && bytes.IndexByte(f.Data, 0) < 0
+}
+
+// buildTree picks the cheapest way to send each file: inline in the tree
+// while it fits in the request budget, otherwise as an uploaded blob.
+func (s *Scaffolder) buildTree(repo string, files []fileEntry) ([]treeEntry, error) {
+	var blobFiles []fileEntry
+	entries := make([]treeEntry, len(files))
+	budget := inlineMaxTotal
+
+	for i, f := range files {
+		entries[i] = treeEntry{Path: f.Path, Mode: f.Mode, Type: "blob"}
+		if inlinable(f) && len(f.Data) <= budget {
+			budget -= len(f.Data)
+			content := string(f.Data)
+			entries[i].Content = &content
+			continue
+		}
+		blobFiles = append(blobFiles, f)
+	}
+
+	if err := s.uploadBlobs(repo, blobFiles); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if entries[i].Content == nil {
+			sha := s.blobs[entries[i].Path]
+			entries[i].SHA = &sha
+		}
+	}
+	return entries, nil
+}
+
+// blobWorkers bounds concurrent blob uploads. GitHub's secondary rate
+// limits punish bursts of concurrent writes, so this stays small.
+const blobWorkers = 6
+
+// uploadBlobs creates a blob for every file not uploaded yet, recording
+// each SHA in the state as it goes so an interrupted push can resume.
+func (s *Scaffolder) uploadBlobs(repo string, files []fileEntry) error {
+	var pending []fileEntry
+	for _, f := range files {
+		if s.blobs[f.Path] != f.SHA {
+			pending = append(pending, f)
+		}
+	}
+
+	total := len(files)
+	done := total - len(pending)
+	s.progress(done, total, "")
+
+	jobs := make(chan fileEntry)
+	errs := make(chan error, len(pending))
+	stop := make(chan struct{})
+	var once sync.Once
+	var wg sync.WaitGroup
+
+	for i := 0; i < blobWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				sha, err := s.createBlob(repo, f.Data)
+				if err != nil {
+					errs <- fmt.Errorf("blob for %s: %w", f.Path, err)
+					once.Do(func() { close(stop) })
+					continue
+				}
+
+				s.mu.Lock()
+				s.blobs[f.Path] = sha
+				done++
+				s.saveState()
+				s.progress(done, total, f.Path)
+				s.mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, f := range pending {
+		select {
+		case jobs <- f:
+		case <-stop:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+func (s *Scaffolder) progress(done int, total int, path string) {
+	if s.Progress != nil {
+		s.Progress(done, total, path)
+	}
+}
@@ -0,0 +1,82 @@
+package scaffold
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kickstartdev/kickstart/internal/debug"
+)
+
+// maxRetries bounds how often a rate limited request is retried.
+const maxRetries = 5
+
+// sleep is a seam for waiting out rate limits.
+var sleep = time.Sleep
+
+// do sends a GitHub API request, waiting and retrying when it is rate
+// limited. Requests with a body must be built with http.NewRequest from a
+// bytes or strings reader so the body can be sent again.
+func (s *Scaffolder) do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, limited := rateLimitWait(resp, attempt)
+		if !limited || attempt == maxRetries {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		debug.Log("rate limited on %s %s, waiting %s", req.Method, req.URL.Path, wait)
+		sleep(wait)
+	}
+}
+
+// rateLimitWait reports whether resp is a rate limit response, and how
+// long to wait before retrying: Retry-After if given, else until
+// X-RateLimit-Reset, else an exponential backoff from a minute as GitHub
+// recommends for secondary rate limits.
+func rateLimitWait(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0)) + time.Second, true
+		}
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		// a 403 is only a rate limit if the body says so, keep the body
+		// readable for callers reporting the real error
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if !strings.Contains(strings.ToLower(string(body)), "rate limit") {
+			return 0, false
+		}
+	}
+
+	return time.Minute << attempt, true
+}
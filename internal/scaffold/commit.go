@@ -0,0 +1,195 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// commitMessage renders the message template for the project's commit: the
+// user's kickstart.commitMessage git config, else the template's
+// commit.message, else fallback.
+func (s *Scaffolder) commitMessage(fallback string) (string, error) {
+	text := gitConfig("kickstart.commitMessage")
+	if text == "" {
+		text = s.Config.Commit.Message
+	}
+	if text == "" {
+		return fallback, nil
+	}
+
+	values := map[string]string{"template": s.Source.Name()}
+	for name, value := range s.Variables {
+		values[name] = value
+	}
+	r, err := newRenderer(s.Config.Variables, values)
+	if err != nil {
+		return "", err
+	}
+	message, err := r.render("commit message", text, nil)
+	if err != nil {
+		return "", err
+	}
+	if message = strings.TrimSpace(message); message == "" {
+		return "", fmt.Errorf("the commit message template renders to an empty message")
+	}
+	return message, nil
+}
+
+// commitIdentity is the author or committer of a commit.
+type commitIdentity struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+// commitRequest is the body of a create-commit request.
+type commitRequest struct {
+	Message   string          `json:"message"`
+	Tree      string          `json:"tree"`
+	Parents   []string        `json:"parents"`
+	Author    *commitIdentity `json:"author,omitempty"`
+	Committer *commitIdentity `json:"committer,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+}
+
+// newCommit prepares a commit of tree on parent, or a root commit when
+// parent is empty. Like git, it takes the author and committer from
+// user.name and user.email, and signs the commit when commit.gpgsign is
+// set. Without an identity GitHub attributes the commit to the token's
+// user.
+func newCommit(tree string, parent string, message string) (*commitRequest, error) {
+	commit := &commitRequest{Message: message, Tree: tree, Parents: []string{}}
+	if parent != "" {
+		commit.Parents = append(commit.Parents, parent)
+	}
+
+	name, email := gitConfig("user.name"), gitConfig("user.email")
+	sign := gitConfigBool("commit.gpgsign")
+	if name == "" || email == "" {
+		if sign {
+			return nil, fmt.Errorf("commit.gpgsign is set, but signing needs user.name and user.email in your git config")
+		}
+		return commit, nil
+	}
+
+	// in UTC, so the date GitHub records matches the signed one exactly
+	now := time.Now().UTC()
+	commit.Author = &commitIdentity{Name: name, Email: email, Date: now.Format(time.RFC3339)}
+	commit.Committer = commit.Author
+	if !sign {
+		return commit, nil
+	}
+
+	// the signature covers the commit object as GitHub will write it,
+	// whose message ends with a newline
+	if !strings.HasSuffix(commit.Message, "\n") {
+		commit.Message += "\n"
+	}
+	ident := fmt.Sprintf("%s <%s> %d +0000", name, email, now.Unix())
+	var payload strings.Builder
+	fmt.Fprintf(&payload, "tree %s\n", tree)
+	for _, p := range commit.Parents {
+		fmt.Fprintf(&payload, "parent %s\n", p)
+	}
+	fmt.Fprintf(&payload, "author %s\ncommitter %s\n\n%s", ident, ident, commit.Message)
+
+	signature, err := signPayload(payload.String(), fmt.Sprintf("%s <%s>", name, email))
+	if err != nil {
+		return nil, err
+	}
+	commit.Signature = signature
+	return commit, nil
+}
+
+// signPayload signs payload the way git signs commits, with the program
+// and key for gpg.format. ident is the key used when user.signingkey isn't
+// set, as with git.
+func signPayload(payload string, ident string) (string, error) {
+	key := gitConfig("user.signingkey")
+
+	var args []string
+	program := ""
+	switch format := gitConfig("gpg.format"); format {
+	case "", "openpgp":
+		program = firstNonEmpty(gitConfig("gpg.openpgp.program"), gitConfig("gpg.program"), "gpg")
+		args = []string{"--status-fd=2", "-bsau", firstNonEmpty(key, ident)}
+	case "x509":
+		program = firstNonEmpty(gitConfig("gpg.x509.program"), "gpgsm")
+		args = []string{"--status-fd=2", "-bsau", firstNonEmpty(key, ident)}
+	case "ssh":
+		if key == "" {
+			return "", fmt.Errorf("gpg.format is ssh, but user.signingkey isn't set")
+		}
+		program = firstNonEmpty(gitConfig("gpg.ssh.program"), "ssh-keygen")
+		// like git, a literal public key is written to a file and the
+		// private key taken from the agent
+		args = []string{"-Y", "sign", "-n", "git"}
+		if literal := strings.TrimPrefix(key, "key::"); literal != key || strings.HasPrefix(key, "ssh-") {
+			keyFile, err := os.CreateTemp("", "kickstart-signingkey-")
+			if err != nil {
+				return "", err
+			}
+			defer os.Remove(keyFile.Name())
+			_, err = keyFile.WriteString(literal + "\n")
+			keyFile.Close()
+			if err != nil {
+				return "", err
+			}
+			args = append(args, "-f", keyFile.Name(), "-U")
+		} else {
+			args = append(args, "-f", expandHome(key))
+		}
+	default:
+		return "", fmt.Errorf("unsupported gpg.format %q", format)
+	}
+
+	cmd := execCommand(program, args...)
+	cmd.Stdin = strings.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	signature, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("signing the commit with %s failed: %s", program, strings.TrimSpace(stderr.String()))
+	}
+	if len(bytes.TrimSpace(signature)) == 0 {
+		return "", fmt.Errorf("signing the commit with %s produced no signature", program)
+	}
+	return string(signature), nil
+}
+
+// gitConfig returns the value of key in the user's git config, empty if it
+// isn't set or git isn't installed.
+func gitConfig(key string) string {
+	output, err := execCommand("git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func gitConfigBool(key string) bool {
+	output, err := execCommand("git", "config", "--type=bool", "--get", key).Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
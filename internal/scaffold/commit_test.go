@@ -0,0 +1,64 @@
+package scaffold
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+func TestCommitMessage(t *testing.T) {
+	const fallback = "Initial scaffold from acme/go-service"
+
+	tests := []struct {
+		name string
+		// gitConfig is the user's kickstart.commitMessage, message the
+		// template's commit.message
+		gitConfig string
+		message   string
+		want      string
+		wantErr   bool
+	}{
+		{name: "fallback", want: fallback},
+		{name: "template message", message: "Create {{project_name}} from {{.template}}", want: "Create my-app from acme/go-service"},
+		{name: "filters", message: "feat: {{title (replace \"-\" \" \" project_name)}}", want: "feat: My App"},
+		{name: "conditions", message: "init{{if .use_docker}} with docker{{end}}", want: "init with docker"},
+		{name: "trimmed", message: "\n  chore: scaffold\n\n", want: "chore: scaffold"},
+		{name: "git config wins", gitConfig: "Start {{.project_name}}", message: "ignored", want: "Start my-app"},
+		{name: "renders empty", message: "{{if false}}x{{end}}  ", wantErr: true},
+		{name: "unknown variable", message: "{{.missing}}", wantErr: true},
+		{name: "invalid template", message: "{{project_name", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// outside any repository, with a global config of our own
+			t.Chdir(t.TempDir())
+			global := filepath.Join(t.TempDir(), "gitconfig")
+			writeTestFile(t, global, ptr(""))
+			t.Setenv("GIT_CONFIG_GLOBAL", global)
+			t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+			if tt.gitConfig != "" {
+				if err := execCommand("git", "config", "--global", "kickstart.commitMessage", tt.gitConfig).Run(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := &Scaffolder{
+				Source:    &GitHubSource{Owner: "acme", Repo: "go-service"},
+				Variables: map[string]string{"project_name": "my-app", "use_docker": "yes"},
+				Config: github.TemplateConfig{
+					Variables: []github.Variable{{Name: "project_name"}, {Name: "use_docker", Type: "bool"}},
+					Commit:    github.CommitConfig{Message: tt.message},
+				},
+			}
+			got, err := s.commitMessage(fallback)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commitMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("commitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
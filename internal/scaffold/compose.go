@@ -0,0 +1,253 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// layer is a skeleton directory of a template, overlaid onto the project
+// in order.
+type layer struct {
+	source Source
+	dir    string
+}
+
+// LoadConfig reads the template.yaml of tmpl merged with the templates it
+// extends. The returned template is pinned to the commits its config was
+// read from, its own and those of every template it extends or includes,
+// so scaffolding it renders the files of the same versions.
+func LoadConfig(token string, tmpl github.Template) (github.Template, error) {
+	src := SourceFor(token, tmpl)
+	if _, ok := src.(*GitHubSource); ok {
+		revision, err := src.Revision()
+		if err != nil {
+			return tmpl, err
+		}
+		tmpl.Revision = revision
+	}
+
+	bases := make(map[string]string)
+	cfg, _, err := compose(token, src, nil, bases)
+	if err != nil {
+		return tmpl, err
+	}
+	if err := validateVariables(cfg.Variables); err != nil {
+		return tmpl, fmt.Errorf("template.yaml of %s: %w", src.Name(), err)
+	}
+	tmpl.Config = *cfg
+	tmpl.Bases = bases
+	return tmpl, nil
+}
+
+// compose resolves the templates src extends and the fragments it
+// includes. It returns the merged config and the skeleton layers in
+// precedence order: the base template's (recursively), then each include
+// in order, then src's own skeleton. chain holds the templates extending
+// src, to detect cycles. GitHub templates referred to are pinned to their
+// commit in bases, and resolved and added to it when missing.
+func compose(token string, src Source, chain []string, bases map[string]string) (*github.TemplateConfig, []layer, error) {
+	if slices.Contains(chain, src.Name()) {
+		return nil, nil, fmt.Errorf("templates extend each other: %s -> %s", strings.Join(chain, " -> "), src.Name())
+	}
+	chain = append(chain, src.Name())
+
+	cfg, err := src.Config()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var layers []layer
+	if cfg.Extends != "" {
+		base, err := templateRef(token, src, cfg.Extends, bases)
+		if err != nil {
+			return nil, nil, fmt.Errorf("extends in %s: %w", src.Name(), err)
+		}
+		baseCfg, baseLayers, err := compose(token, base, chain, bases)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg = mergeConfig(baseCfg, cfg)
+		layers = baseLayers
+	}
+
+	for _, include := range cfg.Include {
+		// "[<template>:]<dir>", a directory of this template by default
+		fragment, dir := src, include
+		if i := strings.LastIndex(include, ":"); i >= 0 {
+			fragment, err = templateRef(token, src, include[:i], bases)
+			if err != nil {
+				return nil, nil, fmt.Errorf("include in %s: %w", src.Name(), err)
+			}
+			dir = include[i+1:]
+		}
+		layers = append(layers, layer{source: fragment, dir: strings.Trim(dir, "/")})
+	}
+
+	return cfg, append(layers, layer{source: src, dir: "skeleton"}), nil
+}
+
+// templateRef resolves a template reference made from src: "owner/repo",
+// optionally with "@ref", or for local templates a path relative to src.
+// GitHub templates are pinned to the commit bases records for the
+// reference, or the one it resolves to now, which is then recorded.
+func templateRef(token string, src Source, ref string, bases map[string]string) (Source, error) {
+	if strings.HasPrefix(ref, ".") || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "file://") {
+		local, ok := src.(*LocalSource)
+		if !ok {
+			return nil, fmt.Errorf("%s: only local templates can refer to local paths", ref)
+		}
+		dir := strings.TrimPrefix(ref, "file://")
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(local.Dir, dir)
+		}
+		return &LocalSource{Dir: dir}, nil
+	}
+
+	repo, version, _ := strings.Cut(ref, "@")
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("%q is not owner/repo[@ref] or a local path", ref)
+	}
+	if version == "" {
+		version = "HEAD"
+	}
+
+	key := repo + "@" + version
+	source := &GitHubSource{Token: token, Owner: owner, Repo: name, Ref: version}
+	if sha, ok := bases[key]; ok {
+		source.Ref = sha
+		return source, nil
+	}
+	sha, err := source.Revision()
+	if err != nil {
+		return nil, err
+	}
+	if bases != nil {
+		bases[key] = sha
+	}
+	return source, nil
+}
+
+// mergeConfig layers child over base. Variables keep the base's order,
+// with the child's definition winning and its new variables last. Rule
+// lists and hooks are concatenated, base first. Everything else is the
+// child's, falling back to the base's delimiters and commit message.
+func mergeConfig(base *github.TemplateConfig, child *github.TemplateConfig) *github.TemplateConfig {
+	merged := *child
+
+	merged.Variables = slices.Clone(base.Variables)
+	for _, v := range child.Variables {
+		i := slices.IndexFunc(merged.Variables, func(b github.Variable) bool { return b.Name == v.Name })
+		if i >= 0 {
+			merged.Variables[i] = v
+		} else {
+			merged.Variables = append(merged.Variables, v)
+		}
+	}
+
+	merged.Render = slices.Concat(base.Render, child.Render)
+	merged.CopyOnly = slices.Concat(base.CopyOnly, child.CopyOnly)
+	merged.DelimiterOverrides = slices.Concat(base.DelimiterOverrides, child.DelimiterOverrides)
+	merged.Exclude = slices.Concat(base.Exclude, child.Exclude)
+	merged.Ignore = slices.Concat(base.Ignore, child.Ignore)
+	merged.Hooks.PostCreate = slices.Concat(base.Hooks.PostCreate, child.Hooks.PostCreate)
+	if merged.Delimiters == nil {
+		merged.Delimiters = base.Delimiters
+	}
+	if merged.Commit.Message == "" {
+		merged.Commit.Message = base.Commit.Message
+	}
+
+	return &merged
+}
+
+// fetchLayers overlays every skeleton layer of the template into dest.
+// Each layer is fetched on its own first, so a later layer can replace a
+// file or symlink of an earlier one without writing through it.
+func (s *Scaffolder) fetchLayers() error {
+	variant, err := s.variant()
+	if err != nil {
+		return err
+	}
+	if s.Config.Extends == "" && len(s.Config.Include) == 0 && variant == nil {
+		return s.Source.Fetch("skeleton", s.OutputDir)
+	}
+
+	layers := []layer{{source: s.Source, dir: "skeleton"}}
+	if s.Config.Extends != "" || len(s.Config.Include) > 0 {
+		if _, layers, err = compose(s.Token, s.Source, nil, s.Bases); err != nil {
+			return err
+		}
+	}
+
+	// the variant swaps or extends the template's own skeleton, the last layer
+	if variant != nil {
+		own := &layers[len(layers)-1]
+		if variant.Skeleton != "" {
+			own.dir = strings.Trim(variant.Skeleton, "/")
+		}
+		if variant.Overlay != "" {
+			layers = append(layers, layer{source: s.Source, dir: strings.Trim(variant.Overlay, "/")})
+		}
+	}
+
+	staging := s.OutputDir + ".layers"
+	defer os.RemoveAll(staging)
+
+	for i, l := range layers {
+		dir := filepath.Join(staging, fmt.Sprint(i))
+		if err := l.source.Fetch(l.dir, dir); err != nil {
+			return fmt.Errorf("%s: %w", l.source.Name(), err)
+		}
+		if err := overlay(dir, s.OutputDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overlay copies src over dest. Where both have a .kickstartignore the
+// rules are combined rather than replaced.
+func overlay(src string, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(src, path)
+		target := filepath.Join(dest, relPath)
+
+		existing, err := os.Lstat(target)
+		exists := err == nil
+		if info.IsDir() {
+			if exists && !existing.IsDir() {
+				os.Remove(target)
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if exists && existing.IsDir() {
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+		} else if exists && existing.Mode()&os.ModeSymlink != 0 {
+			os.Remove(target)
+		}
+
+		data, mode, err := readEntry(path, info)
+		if err != nil {
+			return err
+		}
+		if info.Name() == ignoreFile && exists && existing.Mode().IsRegular() {
+			earlier, err := os.ReadFile(target)
+			if err != nil {
+				return err
+			}
+			data = append(append(earlier, '\n'), data...)
+		}
+		return writeEntry(target, mode, data)
+	})
+}
@@ -0,0 +1,271 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+func TestMergeConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		base, child github.TemplateConfig
+		want        github.TemplateConfig
+	}{
+		{
+			name: "variables keep the base's order",
+			base: github.TemplateConfig{Variables: []github.Variable{
+				{Name: "project_name"}, {Name: "language", Default: "go"},
+			}},
+			child: github.TemplateConfig{Variables: []github.Variable{
+				{Name: "use_docker"}, {Name: "language", Default: "rust"},
+			}},
+			want: github.TemplateConfig{Variables: []github.Variable{
+				{Name: "project_name"}, {Name: "language", Default: "rust"}, {Name: "use_docker"},
+			}},
+		},
+		{
+			name: "rules concatenate, base first",
+			base: github.TemplateConfig{
+				Render:   []string{"*.go"},
+				CopyOnly: []string{"vendor/**"},
+				Exclude:  []github.ExcludeRule{{Paths: []string{"a"}}},
+				Ignore:   []string{"*.bak"},
+				Hooks:    github.Hooks{PostCreate: []string{"make"}},
+			},
+			child: github.TemplateConfig{
+				Render:   []string{"*.md"},
+				CopyOnly: []string{"assets/**"},
+				Exclude:  []github.ExcludeRule{{Paths: []string{"b"}, When: "not x"}},
+				Ignore:   []string{"*.tmp"},
+				Hooks:    github.Hooks{PostCreate: []string{"make test"}},
+			},
+			want: github.TemplateConfig{
+				Render:   []string{"*.go", "*.md"},
+				CopyOnly: []string{"vendor/**", "assets/**"},
+				Exclude:  []github.ExcludeRule{{Paths: []string{"a"}}, {Paths: []string{"b"}, When: "not x"}},
+				Ignore:   []string{"*.bak", "*.tmp"},
+				Hooks:    github.Hooks{PostCreate: []string{"make", "make test"}},
+			},
+		},
+		{
+			name: "falls back to the base's delimiters and message",
+			base: github.TemplateConfig{
+				Delimiters: []string{"[[", "]]"},
+				Commit:     github.CommitConfig{Message: "base"},
+			},
+			child: github.TemplateConfig{Name: "child"},
+			want: github.TemplateConfig{
+				Name:       "child",
+				Delimiters: []string{"[[", "]]"},
+				Commit:     github.CommitConfig{Message: "base"},
+			},
+		},
+		{
+			name: "the child's settings win",
+			base: github.TemplateConfig{
+				Name:       "base",
+				Delimiters: []string{"[[", "]]"},
+				TmplSuffix: true,
+				Repository: github.RepoConfig{Owner: "base-org"},
+				Commit:     github.CommitConfig{Message: "base"},
+			},
+			child: github.TemplateConfig{
+				Name:       "child",
+				Delimiters: []string{"<%", "%>"},
+				Repository: github.RepoConfig{Visibility: "public"},
+				Commit:     github.CommitConfig{Message: "child"},
+			},
+			want: github.TemplateConfig{
+				Name:       "child",
+				Delimiters: []string{"<%", "%>"},
+				Repository: github.RepoConfig{Visibility: "public"},
+				Commit:     github.CommitConfig{Message: "child"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeConfig(&tt.base, &tt.child)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("mergeConfig() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompose(t *testing.T) {
+	tests := []struct {
+		name string
+		// template.yaml of each local template, by directory
+		templates map[string]string
+		// layers as "<template dir>:<skeleton dir>"
+		wantLayers []string
+		wantErr    string
+	}{
+		{
+			name:       "standalone",
+			templates:  map[string]string{"app": "name: app\n"},
+			wantLayers: []string{"app:skeleton"},
+		},
+		{
+			name: "extends and includes",
+			templates: map[string]string{
+				"app":  "extends: ../base\ninclude:\n  - fragments/ci\n  - ../shared:docker\n",
+				"base": "extends: ../root\n",
+				"root": "name: root\n",
+			},
+			wantLayers: []string{"root:skeleton", "base:skeleton", "app:fragments/ci", "shared:docker", "app:skeleton"},
+		},
+		{
+			name:      "extends itself",
+			templates: map[string]string{"app": "extends: .\n"},
+			wantErr:   "templates extend each other",
+		},
+		{
+			name: "cycle",
+			templates: map[string]string{
+				"app":  "extends: ../base\n",
+				"base": "extends: ../root\n",
+				"root": "extends: ../app\n",
+			},
+			wantErr: "templates extend each other",
+		},
+		{
+			name:      "missing base",
+			templates: map[string]string{"app": "extends: ../base\n"},
+			wantErr:   "template.yaml not found",
+		},
+		{
+			name:      "GitHub template from a path",
+			templates: map[string]string{"app": "extends: owner\n"},
+			wantErr:   "is not owner/repo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for dir, config := range tt.templates {
+				writeTestFile(t, filepath.Join(root, dir, "template.yaml"), ptr(config))
+			}
+
+			_, layers, err := compose("", &LocalSource{Dir: filepath.Join(root, "app")}, nil, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("compose() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, l := range layers {
+				dir, _ := filepath.Rel(root, l.source.Name())
+				got = append(got, filepath.ToSlash(dir)+":"+l.dir)
+			}
+			if !reflect.DeepEqual(got, tt.wantLayers) {
+				t.Errorf("layers = %q, want %q", got, tt.wantLayers)
+			}
+		})
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	tests := []struct {
+		name string
+		// lower and upper layer entries: file contents, or "->target"
+		// for a symlink
+		lower, upper map[string]string
+		want         map[string]string
+	}{
+		{
+			name:  "adds and replaces files",
+			lower: map[string]string{"a.txt": "lower", "b.txt": "lower"},
+			upper: map[string]string{"b.txt": "upper", "c/d.txt": "upper"},
+			want:  map[string]string{"a.txt": "lower", "b.txt": "upper", "c/d.txt": "upper"},
+		},
+		{
+			name:  "file replaces a symlink without writing through it",
+			lower: map[string]string{"target.txt": "lower", "link": "->target.txt"},
+			upper: map[string]string{"link": "upper"},
+			want:  map[string]string{"target.txt": "lower", "link": "upper"},
+		},
+		{
+			name:  "file replaces a directory",
+			lower: map[string]string{"config/app.yaml": "lower"},
+			upper: map[string]string{"config": "upper"},
+			want:  map[string]string{"config": "upper"},
+		},
+		{
+			name:  "directory replaces a file",
+			lower: map[string]string{"config": "lower"},
+			upper: map[string]string{"config/app.yaml": "upper"},
+			want:  map[string]string{"config/app.yaml": "upper"},
+		},
+		{
+			name:  "ignore files are combined",
+			lower: map[string]string{ignoreFile: "*.log"},
+			upper: map[string]string{ignoreFile: "*.tmp"},
+			want:  map[string]string{ignoreFile: "*.log\n*.tmp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			lower, upper := filepath.Join(root, "lower"), filepath.Join(root, "upper")
+			writeLayer(t, lower, tt.lower)
+			writeLayer(t, upper, tt.upper)
+
+			if err := overlay(upper, lower); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			err := filepath.Walk(lower, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				relPath, _ := filepath.Rel(lower, path)
+				data, mode, err := readEntry(path, info)
+				if err != nil {
+					return err
+				}
+				if mode == modeSymlink {
+					data = append([]byte("->"), data...)
+				}
+				got[filepath.ToSlash(relPath)] = string(data)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("overlay() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeLayer(t *testing.T, dir string, entries map[string]string) {
+	t.Helper()
+	for path, content := range entries {
+		path = filepath.Join(dir, path)
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			writeTestFile(t, path, nil)
+			if err := os.Symlink(target, path); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		writeTestFile(t, path, &content)
+	}
+}
@@ -0,0 +1,156 @@
+package scaffold
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Report describes what a dry run would have created.
+type Report struct {
+	Files        []ReportFile
+	Placeholders []Placeholder
+	Repo         RepoSettings
+}
+
+type ReportFile struct {
+	Path string
+	Size int64
+	Mode string
+}
+
+// Placeholder is template syntax in a file copied verbatim, by copy_only
+// or because render rules skip it. It is either meant to be literal or a
+// variable the file was meant to have replaced.
+type Placeholder struct {
+	Path string
+	Line int
+	Text string
+}
+
+// RepoSettings are the settings the repository would be created with.
+type RepoSettings struct {
+	Name        string
+	Owner       string
+	Visibility  string
+	Description string
+	Homepage    string
+	Topics      []string
+	Branch      string
+	Template    string
+}
+
+// inspect builds the dry run Report from the rendered project.
+func (s *Scaffolder) inspect() error {
+	settings := s.Config.Repository
+	report := &Report{
+		Repo: RepoSettings{
+			Name:        s.ProjectName,
+			Owner:       settings.Owner,
+			Visibility:  settings.Visibility,
+			Description: settings.Description,
+			Homepage:    settings.Homepage,
+			Topics:      settings.Topics,
+			Branch:      s.DefaultBranch(),
+			Template:    s.Source.Name(),
+		},
+	}
+	if report.Repo.Visibility == "" {
+		report.Repo.Visibility = "private"
+	}
+	if _, ok := s.Source.(*GitHubSource); ok {
+		ref := s.Branch
+		if ref == "" {
+			ref = "HEAD"
+		}
+		report.Repo.Template += "@" + ref
+		if s.Revision != "" {
+			report.Repo.Template += " (" + s.Revision[:7] + ")"
+		}
+	}
+
+	if report.Repo.Owner == "" {
+		if username, err := s.getUsername(); err == nil {
+			report.Repo.Owner = username
+		}
+	}
+
+	err := filepath.Walk(s.OutputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(s.OutputDir, path)
+		relPath = filepath.ToSlash(relPath)
+
+		_, mode, err := readEntry(path, info)
+		if err != nil {
+			return err
+		}
+		report.Files = append(report.Files, ReportFile{Path: relPath, Size: info.Size(), Mode: mode})
+
+		// delimiters left in a rendered file are raw block output, but a
+		// file copied verbatim keeps any variable it was meant to replace
+		source, ok := s.sources[relPath]
+		if !ok || mode == modeSymlink {
+			return nil
+		}
+		if render, err := s.shouldRender(source, path); err != nil || render {
+			return err
+		}
+		if binary, err := isBinary(path); err != nil || binary {
+			return err
+		}
+
+		found, err := findPlaceholders(path, relPath, s.placeholderPattern(source))
+		if err != nil {
+			return err
+		}
+		report.Placeholders = append(report.Placeholders, found...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Report = report
+	return nil
+}
+
+// placeholderPattern matches the delimiters the skeleton file relPath
+// would be rendered with.
+func (s *Scaffolder) placeholderPattern(relPath string) *regexp.Regexp {
+	pair := s.delimiters(relPath)
+	if len(pair) != 2 {
+		pair = []string{"{{", "}}"}
+	}
+	left, right := regexp.QuoteMeta(pair[0]), regexp.QuoteMeta(pair[1])
+	return regexp.MustCompile(left + `.*?` + right)
+}
+
+func findPlaceholders(path string, relPath string, pattern *regexp.Regexp) ([]Placeholder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var found []Placeholder
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		for _, match := range pattern.FindAllString(scanner.Text(), -1) {
+			found = append(found, Placeholder{Path: relPath, Line: line, Text: strings.TrimSpace(match)})
+		}
+	}
+	// minified files can have lines too long to scan, skip the rest of them
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return nil, err
+	}
+	return found, nil
+}
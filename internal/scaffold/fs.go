@@ -0,0 +1,114 @@
+package scaffold
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// git file modes, as used by the trees API
+const (
+	modeFile       = "100644"
+	modeExecutable = "100755"
+	modeSymlink    = "120000"
+)
+
+// writeEntry writes data to dest according to a git file mode. For
+// symlinks data is the link target.
+func writeEntry(dest string, mode string, data []byte) error {
+	switch mode {
+	case modeSymlink:
+		os.Remove(dest)
+		if err := os.Symlink(string(data), dest); err == nil {
+			return nil
+		}
+		// like git with core.symlinks=false, fall back to a file holding
+		// the target where symlinks aren't supported
+		return os.WriteFile(dest, data, 0644)
+	case modeExecutable:
+		if err := os.WriteFile(dest, data, 0755); err != nil {
+			return err
+		}
+		// WriteFile only applies the mode to new files
+		return os.Chmod(dest, 0755)
+	default:
+		return os.WriteFile(dest, data, 0644)
+	}
+}
+
+// readEntry is the reverse of writeEntry: it returns the content and git
+// file mode of a local file. info must come from Lstat.
+func readEntry(path string, info os.FileInfo) ([]byte, string, error) {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return []byte(target), modeSymlink, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Mode()&0111 != 0 {
+		return data, modeExecutable, nil
+	}
+	return data, modeFile, nil
+}
+
+// linkOrCopy hard links src to dst, falling back to a copy when linking
+// isn't possible (e.g. across filesystems). src is left in place.
+func linkOrCopy(src string, dst string, mode os.FileMode) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// linkInside reports whether a symlink at relPath, slash-separated and
+// relative to the project, pointing at target stays inside the project.
+// Targets may only climb with leading ".." segments, which resolve through
+// the link's own parent directories: a ".." after a name could climb out
+// of wherever another symlink led.
+func linkInside(relPath string, target string) bool {
+	if target == "" || path.IsAbs(target) || filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return false
+	}
+
+	depth := 0
+	if dir := path.Dir(relPath); dir != "." {
+		depth = strings.Count(dir, "/") + 1
+	}
+	descended := false
+	for _, seg := range strings.Split(filepath.ToSlash(target), "/") {
+		switch seg {
+		case "", ".":
+		case "..":
+			if descended || depth == 0 {
+				return false
+			}
+			depth--
+		default:
+			descended = true
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package scaffold
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// hookSteps turns the template's post_create hooks into steps that run in
+// the cloned project. Commands are rendered with the template variables
+// first, so a hook can use e.g. "go mod init {{module}}".
+func (s *Scaffolder) hookSteps() []Step {
+	if !s.RunHooks {
+		return nil
+	}
+
+	var steps []Step
+	for i, command := range s.Config.Hooks.PostCreate {
+		steps = append(steps, Step{
+			Name:   "Running " + command,
+			Fn:     func() error { return s.runHook(i, command) },
+			Output: func() string { return s.hookOutput[i] },
+		})
+	}
+	return steps
+}
+
+func (s *Scaffolder) runHook(i int, command string) error {
+	r, err := newRenderer(s.Config.Variables, s.Variables)
+	if err != nil {
+		return err
+	}
+	command, err = r.render("hook", command, s.Config.Delimiters)
+	if err != nil {
+		return err
+	}
+
+	cmd := execCommand("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = execCommand("cmd", "/C", command)
+	}
+	cmd.Dir = s.OutputDir
+
+	output, err := cmd.CombinedOutput()
+	if s.hookOutput == nil {
+		s.hookOutput = make(map[int]string)
+	}
+	s.hookOutput[i] = string(output)
+	if err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ignoreFile lists skeleton paths that belong to the template rather than
+// the generated project, in gitignore syntax.
+const ignoreFile = ".kickstartignore"
+
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// parseIgnore parses gitignore-style lines: blank lines and # comments are
+// skipped, "!" re-includes, a trailing "/" only matches directories.
+func parseIgnore(lines []string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// like gitignore, a slash anywhere but the end anchors the pattern
+		if strings.Contains(line, "/") && !strings.HasPrefix(line, "/") && !strings.HasPrefix(line, "**/") {
+			line = "/" + line
+		}
+		// and a trailing "/**" matches what's inside a directory, but not the
+		// directory itself, which would leave nothing to re-include
+		if dir, ok := strings.CutSuffix(line, "/**"); ok {
+			line = dir + "/*/**"
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignored reports whether relPath is ignored, either itself or through one
+// of its parent directories. The last matching rule wins.
+func ignored(rules []ignoreRule, relPath string, isDir bool) bool {
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if matchIgnore(rules, strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return matchIgnore(rules, relPath, isDir)
+}
+
+func matchIgnore(rules []ignoreRule, relPath string, isDir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchGlob(rule.pattern, relPath) {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+func readIgnoreFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// applyIgnore removes every path ignored by the template's ignore rules or
+// the skeleton's .kickstartignore from the downloaded skeleton. The file's
+// rules aren't added to Config: they only apply to the downloaded skeleton,
+// which a resumed run either downloads again or has already filtered, and
+// Config is saved with the state, so each retry would repeat them.
+func (s *Scaffolder) applyIgnore() error {
+	path := filepath.Join(s.OutputDir, ignoreFile)
+	lines, err := readIgnoreFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	rules := parseIgnore(append(slices.Clone(s.Config.Ignore), lines...))
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return filepath.Walk(s.OutputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(s.OutputDir, path)
+		if relPath == "." {
+			return nil
+		}
+
+		if !matchIgnore(rules, filepath.ToSlash(relPath), info.IsDir()) {
+			return nil
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
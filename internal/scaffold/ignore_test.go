@@ -0,0 +1,114 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+func TestParseIgnore(t *testing.T) {
+	tests := []struct {
+		line string
+		want []ignoreRule
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"# comment", nil},
+		{"*.log", []ignoreRule{{pattern: "*.log"}}},
+		{"*.log  ", []ignoreRule{{pattern: "*.log"}}},
+		{"!keep.log", []ignoreRule{{pattern: "keep.log", negate: true}}},
+		{`\#literal`, []ignoreRule{{pattern: "#literal"}}},
+		{`\!literal`, []ignoreRule{{pattern: "!literal"}}},
+		{"build/", []ignoreRule{{pattern: "build", dirOnly: true}}},
+		{"docs/internal", []ignoreRule{{pattern: "/docs/internal"}}},
+		{"/root.txt", []ignoreRule{{pattern: "/root.txt"}}},
+		{"**/tmp", []ignoreRule{{pattern: "**/tmp"}}},
+		{"secrets/**", []ignoreRule{{pattern: "/secrets/*/**"}}},
+		{"!docs/internal/", []ignoreRule{{pattern: "/docs/internal", negate: true, dirOnly: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := parseIgnore([]string{tt.line}); !slices.Equal(got, tt.want) {
+				t.Errorf("parseIgnore(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	rules := parseIgnore([]string{
+		"*.log",
+		"!important.log",
+		"build/",
+		"docs/drafts",
+		"/TODO",
+		"secrets/**",
+		"!secrets/README.md",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"debug.log", false, true},
+		{"logs/debug.log", false, true},
+		{"important.log", false, false},
+		{"main.go", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"build/out.bin", false, true},
+		{"src/build/out.bin", false, true},
+		{"docs/drafts", true, true},
+		{"docs/drafts/post.md", false, true},
+		{"site/docs/drafts", true, false},
+		{"TODO", false, true},
+		{"notes/TODO", false, false},
+		{"secrets", true, false},
+		{"secrets/key.pem", false, true},
+		{"secrets/nested/key.pem", false, true},
+		{"secrets/README.md", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ignored(rules, tt.path, tt.isDir); got != tt.want {
+				t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyIgnoreKeepsConfig(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		ignoreFile:      "*.log\n",
+		"debug.log":     "",
+		"template.bak":  "",
+		"src/main.go":   "package main\n",
+		"src/trace.log": "",
+	} {
+		writeTestFile(t, filepath.Join(dir, path), ptr(content))
+	}
+
+	s := &Scaffolder{OutputDir: dir, Config: github.TemplateConfig{Ignore: []string{"*.bak"}}}
+	if err := s.applyIgnore(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{ignoreFile, "debug.log", "template.bak", "src/trace.log"} {
+		if _, err := os.Stat(filepath.Join(dir, path)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src/main.go")); err != nil {
+		t.Errorf("src/main.go was removed: %v", err)
+	}
+	if !slices.Equal(s.Config.Ignore, []string{"*.bak"}) {
+		t.Errorf("Config.Ignore = %q, want it unchanged", s.Config.Ignore)
+	}
+}
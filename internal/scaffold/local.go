@@ -0,0 +1,63 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Step 3 (local mode): turn the rendered project into a git repository
+// with a single initial commit instead of creating one on GitHub.
+func (s *Scaffolder) initLocalRepo() error {
+	// start from scratch if a previous attempt got part way
+	os.RemoveAll(filepath.Join(s.OutputDir, ".git"))
+
+	if err := keepEmptyDirs(s.OutputDir); err != nil {
+		return err
+	}
+
+	// git applies the user's identity and commit.gpgsign itself
+	message, err := s.commitMessage("Initial scaffold from " + s.Repo)
+	if err != nil {
+		return err
+	}
+
+	commands := [][]string{
+		{"git", "init", "--quiet"},
+		{"git", "symbolic-ref", "HEAD", "refs/heads/" + s.DefaultBranch()},
+		{"git", "add", "--all"},
+		{"git", "commit", "--quiet", "-m", message},
+	}
+	if s.Remote != "" {
+		commands = append(commands, []string{"git", "remote", "add", "origin", s.Remote})
+	}
+
+	for _, args := range commands {
+		cmd := execCommand(args[0], args[1:]...)
+		cmd.Dir = s.OutputDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s %s failed: %s", args[0], args[1], string(output))
+		}
+	}
+
+	return nil
+}
+
+// keepEmptyDirs writes a .gitkeep into every empty directory below root,
+// as collectFiles does for a pushed project, since git can't store them.
+func keepEmptyDirs(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || path == root {
+			return err
+		}
+		children, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		if len(children) == 0 {
+			return os.WriteFile(filepath.Join(path, ".gitkeep"), nil, 0644)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+	"gopkg.in/yaml.v3"
+)
+
+// Set via ldflags at build time
+var Version = "dev"
+
+// ProvenanceFile records in a generated project where it came from.
+const ProvenanceFile = ".kickstart.yaml"
+
+type Provenance struct {
+	Template         TemplateRef       `yaml:"template"`
+	Variant          string            `yaml:"variant,omitempty"`
+	KickstartVersion string            `yaml:"kickstart_version"`
+	Variables        map[string]string `yaml:"variables"`
+}
+
+type TemplateRef struct {
+	// Repo is owner/repo for GitHub templates
+	Repo string `yaml:"repo,omitempty"`
+	// Path is the directory of a local template
+	Path   string `yaml:"path,omitempty"`
+	Ref    string `yaml:"ref,omitempty"`
+	Commit string `yaml:"commit,omitempty"`
+	// Bases are the commits of the templates it extends or includes
+	Bases map[string]string `yaml:"bases,omitempty"`
+}
+
+// ReadProvenance reads the provenance file of the project in dir.
+func ReadProvenance(dir string) (*Provenance, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProvenanceFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var p Provenance
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ProvenanceFile, err)
+	}
+	return &p, nil
+}
+
+func (s *Scaffolder) provenance() Provenance {
+	p := Provenance{
+		Template:         TemplateRef{Commit: s.Revision, Bases: s.Bases},
+		Variant:          s.Variant,
+		KickstartVersion: Version,
+		Variables:        make(map[string]string),
+	}
+
+	if local, ok := s.Source.(*LocalSource); ok {
+		p.Template.Path = local.Dir
+	} else {
+		p.Template.Repo = s.Owner + "/" + s.Repo
+		p.Template.Ref = s.Branch
+	}
+
+	// secrets must never end up in the repository
+	secret := make(map[string]bool)
+	for _, v := range s.Config.Variables {
+		secret[v.Name] = v.Type == github.TypeSecret
+	}
+	for name, value := range s.Variables {
+		if !secret[name] && name != VariantVariable {
+			p.Variables[name] = value
+		}
+	}
+
+	return p
+}
+
+func (s *Scaffolder) writeProvenance() error {
+	data, err := yaml.Marshal(s.provenance())
+	if err != nil {
+		return err
+	}
+	header := []byte("# Generated by kickstart, used to update this project from its template.\n")
+	return os.WriteFile(filepath.Join(s.OutputDir, ProvenanceFile), append(header, data...), 0644)
+}
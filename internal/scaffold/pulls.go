@@ -0,0 +1,54 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type pullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body"`
+}
+
+// createPullRequest opens pr on owner/repo and returns its URL.
+func (s *Scaffolder) createPullRequest(owner string, repo string, pr pullRequest) (string, error) {
+	body, err := json.Marshal(pr)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST",
+		fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls", owner, repo),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to open pull request: %d %s", resp.StatusCode, string(respBody))
+	}
+
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
@@ -0,0 +1,400 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// reserved names can't be registered as variable functions because
+// text/template treats them as keywords or builtins.
+var reserved = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true,
+	"define": true, "template": true, "block": true, "break": true,
+	"continue": true, "nil": true, "true": true, "false": true,
+	"and": true, "or": true, "not": true, "len": true, "index": true,
+	"slice": true, "print": true, "printf": true, "println": true,
+	"html": true, "js": true, "urlquery": true, "call": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"truthy": true,
+}
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// templateErrPattern splits the "<line>[:<col>]: <msg>" left of a
+// text/template error once the template name is stripped.
+var templateErrPattern = regexp.MustCompile(`^(\d+)(?::\d+)?: (.*)$`)
+
+// RenderError reports a skeleton file that failed to render.
+type RenderError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *RenderError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Msg)
+}
+
+// renderer renders skeleton files with text/template. Every variable is
+// available both as data ({{.project_name}}) and as a function
+// ({{project_name}}), so skeletons written for plain {{key}} placeholders
+// keep working alongside {{if}}, {{range}} and filters.
+type renderer struct {
+	data  map[string]any
+	funcs template.FuncMap
+}
+
+func newRenderer(vars []github.Variable, values map[string]string) (*renderer, error) {
+	types := make(map[string]string)
+	for _, v := range vars {
+		types[v.Name] = v.Type
+	}
+
+	r := &renderer{
+		data:  make(map[string]any),
+		funcs: filters(),
+	}
+
+	for name, raw := range values {
+		value, err := parseValue(types[name], raw)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %w", name, err)
+		}
+		r.data[name] = value
+
+		if identPattern.MatchString(name) && !reserved[name] && r.funcs[name] == nil {
+			r.funcs[name] = func() any { return value }
+		}
+	}
+
+	return r, nil
+}
+
+// validateVariables rejects variables whose names would shadow a template
+// keyword, builtin or filter as a function, so {{title}} can't silently
+// mean the filter in one template and an answer in another.
+func validateVariables(vars []github.Variable) error {
+	funcs := filters()
+	for _, v := range vars {
+		switch {
+		case reserved[v.Name]:
+			return fmt.Errorf("variable %q has the name of a template builtin, rename it", v.Name)
+		case funcs[v.Name] != nil:
+			return fmt.Errorf("variable %q has the name of the %s filter, rename it", v.Name, v.Name)
+		}
+	}
+	return nil
+}
+
+// parseValue converts a raw form value into the Go value exposed to
+// templates, based on the variable's declared type.
+func parseValue(typ string, raw string) (any, error) {
+	switch typ {
+	case "bool":
+		switch strings.ToLower(strings.TrimSpace(raw)) {
+		case "", "n", "no", "off":
+			return false, nil
+		case "y", "yes", "on":
+			return true, nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return b, nil
+	case "list":
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	default:
+		return raw, nil
+	}
+}
+
+// render executes text as a template using delims, or the default {{ }}
+// when delims is nil. name identifies the file in errors.
+func (r *renderer) render(name string, text string, delims []string) (string, error) {
+	left, right := "{{", "}}"
+	if len(delims) == 2 {
+		left, right = delims[0], delims[1]
+	}
+
+	text, blocks, err := extractRaw(name, text, left, right)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(name).
+		Delims(left, right).
+		Funcs(r.funcs).
+		Funcs(template.FuncMap{"__raw": func(i int) string { return blocks[i] }}).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return "", renderError(name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r.data); err != nil {
+		return "", renderError(name, err)
+	}
+	return buf.String(), nil
+}
+
+// eval evaluates a condition written as a template expression, e.g.
+// "not include_helm" or `and use_docker (eq language "go")`. Answers stay
+// strings, so eq compares them as typed, but truth follows the answers a
+// bool variable accepts: "no", "n", "off", "false" and "0" are false like
+// an empty answer.
+func (r *renderer) eval(expr string) (bool, error) {
+	cond := &renderer{data: r.data, funcs: maps.Clone(r.funcs)}
+	maps.Copy(cond.funcs, conditionFuncs)
+
+	out, err := cond.render("when "+expr, "{{if truthy ("+expr+")}}true{{end}}", nil)
+	if err != nil {
+		return false, err
+	}
+	return out == "true", nil
+}
+
+// conditionFuncs replace text/template's logic functions in conditions
+// with ones using truthy.
+var conditionFuncs = template.FuncMap{
+	"truthy": truthy,
+	"not":    func(v any) bool { return !truthy(v) },
+	"and": func(first any, rest ...any) any {
+		for _, v := range rest {
+			if !truthy(first) {
+				break
+			}
+			first = v
+		}
+		return first
+	},
+	"or": func(first any, rest ...any) any {
+		for _, v := range rest {
+			if truthy(first) {
+				break
+			}
+			first = v
+		}
+		return first
+	},
+}
+
+// truthy is text/template's notion of truth, except that strings saying
+// no are false.
+func truthy(v any) bool {
+	if s, ok := v.(string); ok {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "", "n", "no", "off", "false", "0":
+			return false
+		}
+		return true
+	}
+	truth, _ := template.IsTrue(v)
+	return truth
+}
+
+// extractRaw replaces every {{raw}}...{{endraw}} block with a call that
+// emits the block's content untouched, so authors can write literal
+// delimiters (Helm charts, GitHub Actions expressions, Jinja) in a template.
+func extractRaw(name string, text string, left string, right string) (string, []string, error) {
+	open, closing := rawTags(left, right)
+
+	var blocks []string
+	var out strings.Builder
+	for {
+		start := open.FindStringIndex(text)
+		if start == nil {
+			out.WriteString(text)
+			break
+		}
+		end := closing.FindStringIndex(text[start[1]:])
+		if end == nil {
+			line := strings.Count(out.String(), "\n") + strings.Count(text[:start[0]], "\n") + 1
+			return "", nil, &RenderError{File: name, Line: line, Msg: "raw block is never closed"}
+		}
+
+		out.WriteString(text[:start[0]])
+		fmt.Fprintf(&out, "%s__raw %d%s", left, len(blocks), right)
+		blocks = append(blocks, text[start[1]:start[1]+end[0]])
+		// keep line numbers in later errors pointing at the original file
+		out.WriteString(strings.Repeat(left+"/*\n*/"+right, strings.Count(blocks[len(blocks)-1], "\n")))
+
+		text = text[start[1]+end[1]:]
+	}
+
+	return out.String(), blocks, nil
+}
+
+// rawPatterns caches the raw and endraw tag patterns of each delimiter
+// pair, as extractRaw runs for every file and path segment.
+var rawPatterns = struct {
+	sync.Mutex
+	tags map[[2]string][2]*regexp.Regexp
+}{tags: make(map[[2]string][2]*regexp.Regexp)}
+
+func rawTags(left string, right string) (*regexp.Regexp, *regexp.Regexp) {
+	rawPatterns.Lock()
+	defer rawPatterns.Unlock()
+
+	key := [2]string{left, right}
+	tags, ok := rawPatterns.tags[key]
+	if !ok {
+		tags[0] = regexp.MustCompile(regexp.QuoteMeta(left) + `-?\s*raw\s*-?` + regexp.QuoteMeta(right))
+		tags[1] = regexp.MustCompile(regexp.QuoteMeta(left) + `-?\s*endraw\s*-?` + regexp.QuoteMeta(right))
+		rawPatterns.tags[key] = tags
+	}
+	return tags[0], tags[1]
+}
+
+// renderPath renders every segment of a slash-separated path. If any
+// segment renders to nothing the whole path is dropped and "" is returned,
+// which lets templates exclude a file or directory by its name alone, e.g.
+// "{{if .use_docker}}Dockerfile{{end}}".
+func (r *renderer) renderPath(path string, delims []string) (string, error) {
+	var out []string
+	for _, seg := range strings.Split(path, "/") {
+		rendered, err := r.render(path, seg, delims)
+
+		if err != nil {
+			return "", err
+		}
+		rendered = strings.Trim(strings.TrimSpace(rendered), "/")
+		if rendered == "" || rendered == "." {
+			return "", nil
+		}
+		// a segment may render to a nested path, but never outside the project
+		for _, part := range strings.Split(rendered, "/") {
+			if part == ".." {
+				return "", &RenderError{File: path, Msg: "path renders outside the project"}
+			}
+			if part != "" && part != "." {
+				out = append(out, part)
+			}
+		}
+	}
+	return strings.Join(out, "/"), nil
+}
+
+func renderError(name string, err error) error {
+	// text/template errors look like `template: <name>:<line>[:<col>]: <msg>`
+	m := templateErrPattern.FindStringSubmatch(strings.TrimPrefix(err.Error(), "template: "+name+":"))
+	if m == nil {
+		return &RenderError{File: name, Msg: err.Error()}
+	}
+	line, _ := strconv.Atoi(m[1])
+	// execution errors repeat the template name, drop it
+	msg := strings.TrimPrefix(m[2], fmt.Sprintf("executing %q at ", name))
+	return &RenderError{File: name, Line: line, Msg: msg}
+}
+
+func filters() template.FuncMap {
+	return template.FuncMap{
+		"upper":       strings.ToUpper,
+		"lower":       strings.ToLower,
+		"title":       titleCase,
+		"trim":        strings.TrimSpace,
+		"snake_case":  func(s string) string { return strings.ToLower(strings.Join(words(s), "_")) },
+		"kebab_case":  func(s string) string { return strings.ToLower(strings.Join(words(s), "-")) },
+		"pascal_case": pascalCase,
+		"camel_case":  camelCase,
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+		"join": func(sep string, items []string) string {
+			return strings.Join(items, sep)
+		},
+		"default": func(def string, s string) string {
+			if s == "" {
+				return def
+			}
+			return s
+		},
+	}
+}
+
+// words splits s into words on separators and case changes, so
+// "my-cool_app", "MyCoolApp" and "myCoolAPP" all split the same way.
+func words(s string) []string {
+	var out []string
+	var cur []rune
+	runes := []rune(s)
+
+	flush := func() {
+		if len(cur) > 0 {
+			out = append(out, string(cur))
+			cur = nil
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(cur) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+
+	return out
+}
+
+func capitalize(w string) string {
+	runes := []rune(strings.ToLower(w))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func pascalCase(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		b.WriteString(capitalize(w))
+	}
+	return b.String()
+}
+
+func camelCase(s string) string {
+	ws := words(s)
+	if len(ws) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(ws[0]))
+	for _, w := range ws[1:] {
+		b.WriteString(capitalize(w))
+	}
+	return b.String()
+}
+
+func titleCase(s string) string {
+	ws := words(s)
+	for i, w := range ws {
+		ws[i] = capitalize(w)
+	}
+	return strings.Join(ws, " ")
+}
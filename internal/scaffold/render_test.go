@@ -0,0 +1,199 @@
+package scaffold
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+func TestEval(t *testing.T) {
+	vars := []github.Variable{
+		{Name: "flag"},
+		{Name: "typed", Type: "bool"},
+		{Name: "language"},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		expr   string
+		want   bool
+	}{
+		{"not no", map[string]string{"flag": "no"}, "not flag", true},
+		{"not false", map[string]string{"flag": "false"}, "not flag", true},
+		{"not empty", map[string]string{"flag": ""}, "not flag", true},
+		{"not yes", map[string]string{"flag": "yes"}, "not flag", false},
+		{"not other string", map[string]string{"flag": "maybe"}, "not flag", false},
+		{"bare no", map[string]string{"flag": "no"}, "flag", false},
+		{"bare y", map[string]string{"flag": "y"}, "flag", true},
+		{"eq no", map[string]string{"flag": "no"}, `eq flag "no"`, true},
+		{"eq y", map[string]string{"flag": "y"}, `eq flag "y"`, true},
+		{"eq other", map[string]string{"flag": "yes"}, `eq flag "no"`, false},
+		{"dot access", map[string]string{"flag": "off"}, "not .flag", true},
+		{"typed bool", map[string]string{"typed": "no"}, "not typed", true},
+		{"and", map[string]string{"flag": "yes", "language": "go"}, `and flag (eq language "go")`, true},
+		{"and with no", map[string]string{"flag": "no", "language": "go"}, `and flag (eq language "go")`, false},
+		{"or with no", map[string]string{"flag": "no", "language": "go"}, `or flag (eq language "go")`, true},
+		{"or all no", map[string]string{"flag": "no", "language": "rust"}, `or flag (eq language "go")`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRenderer(vars, tt.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.eval(tt.expr)
+			if err != nil {
+				t.Fatalf("eval(%q) error = %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("eval(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPath(t *testing.T) {
+	values := map[string]string{
+		"project_name": "My App",
+		"use_docker":   "true",
+		"use_helm":     "false",
+		"module":       "pkg/api",
+		"escape":       "../outside",
+	}
+	vars := []github.Variable{{Name: "use_docker", Type: "bool"}, {Name: "use_helm", Type: "bool"}}
+
+	tests := []struct {
+		name    string
+		path    string
+		delims  []string
+		want    string
+		wantErr bool
+	}{
+		{"plain path", "src/main.go", nil, "src/main.go", false},
+		{"variable in file name", "cmd/{{kebab_case project_name}}.go", nil, "cmd/my-app.go", false},
+		{"variable in directory name", "{{snake_case .project_name}}/main.go", nil, "my_app/main.go", false},
+		{"condition keeps file", "{{if .use_docker}}Dockerfile{{end}}", nil, "Dockerfile", false},
+		{"condition drops file", "{{if .use_helm}}Chart.yaml{{end}}", nil, "", false},
+		{"condition drops directory", "{{if .use_helm}}charts{{end}}/values.yaml", nil, "", false},
+		{"segment renders to nested path", "{{module}}/handler.go", nil, "pkg/api/handler.go", false},
+		{"segment renders outside", "{{escape}}/file", nil, "", true},
+		{"custom delimiters", "[[kebab_case project_name]]/{{keep}}.txt", []string{"[[", "]]"}, "my-app/{{keep}}.txt", false},
+		{"unknown variable", "{{.missing}}/file", nil, "", true},
+	}
+
+	r, err := newRenderer(vars, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.renderPath(tt.path, tt.delims)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("renderPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractRaw(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		left        string
+		right       string
+		want        string
+		wantBlocks  []string
+		wantErrLine int
+	}{
+		{
+			name:  "no raw block",
+			text:  "hello {{name}}",
+			left:  "{{",
+			right: "}}",
+			want:  "hello {{name}}",
+		},
+		{
+			name:       "one block",
+			text:       "a {{raw}}{{ .Values.x }}{{endraw}} b",
+			left:       "{{",
+			right:      "}}",
+			want:       "a {{__raw 0}} b",
+			wantBlocks: []string{"{{ .Values.x }}"},
+		},
+		{
+			name:       "trim markers",
+			text:       "{{-raw-}}${{ x }}{{- endraw }}",
+			left:       "{{",
+			right:      "}}",
+			want:       "{{__raw 0}}",
+			wantBlocks: []string{"${{ x }}"},
+		},
+		{
+			name:       "two blocks",
+			text:       "{{raw}}1{{endraw}}-{{raw}}2{{endraw}}",
+			left:       "{{",
+			right:      "}}",
+			want:       "{{__raw 0}}-{{__raw 1}}",
+			wantBlocks: []string{"1", "2"},
+		},
+		{
+			name:       "line numbers kept",
+			text:       "{{raw}}a\nb\n{{endraw}}",
+			left:       "{{",
+			right:      "}}",
+			want:       "{{__raw 0}}{{/*\n*/}}{{/*\n*/}}",
+			wantBlocks: []string{"a\nb\n"},
+		},
+		{
+			name:       "custom delimiters",
+			text:       "[[raw]]{{ keep }}[[endraw]] [[name]]",
+			left:       "[[",
+			right:      "]]",
+			want:       "[[__raw 0]] [[name]]",
+			wantBlocks: []string{"{{ keep }}"},
+		},
+		{
+			name:  "other delimiters ignored",
+			text:  "{{raw}}x{{endraw}}",
+			left:  "[[",
+			right: "]]",
+			want:  "{{raw}}x{{endraw}}",
+		},
+		{
+			name:        "never closed",
+			text:        "one\ntwo\n{{raw}}three",
+			left:        "{{",
+			right:       "}}",
+			wantErrLine: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, blocks, err := extractRaw("file", tt.text, tt.left, tt.right)
+			if tt.wantErrLine > 0 {
+				var renderErr *RenderError
+				if !errors.As(err, &renderErr) || renderErr.Line != tt.wantErrLine {
+					t.Fatalf("extractRaw() error = %v, want one on line %d", err, tt.wantErrLine)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("extractRaw() = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(blocks, tt.wantBlocks) {
+				t.Errorf("blocks = %q, want %q", blocks, tt.wantBlocks)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// Step 3: Create a new GitHub repo, in the configured organization if
+// there is one, with the configured settings and topics
+func (s *Scaffolder) createRepo() error {
+	settings := s.Config.Repository
+	if s.CreatedRepo != "" {
+		// a retry after the repo was created, only the topics are left
+		return s.setRepoTopics()
+	}
+	visibility := settings.Visibility
+	if visibility == "" {
+		visibility = "private"
+	}
+
+	username, err := s.getUsername()
+	if err != nil {
+		return err
+	}
+
+	// created empty, pushFiles seeds it with a file of its own rather than
+	// the README auto_init would add
+	payload := map[string]any{
+		"name":        s.ProjectName,
+		"description": settings.Description,
+		"homepage":    settings.Homepage,
+	}
+	url := "https://api.github.com/user/repos"
+	if settings.Owner != "" && !strings.EqualFold(settings.Owner, username) {
+		url = fmt.Sprintf("https://api.github.com/orgs/%s/repos", settings.Owner)
+		payload["visibility"] = visibility
+	} else {
+		if visibility == "internal" {
+			return fmt.Errorf("internal repositories can only be created in an organization")
+		}
+		payload["private"] = visibility != "public"
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		// someone else's repo, rolling back must leave it alone
+		if resp.StatusCode == http.StatusUnprocessableEntity && strings.Contains(string(respBody), "already exists") {
+			return fmt.Errorf("a repository named %s already exists, pick another project name", s.ProjectName)
+		}
+		return fmt.Errorf("failed to create repo: %d %s", resp.StatusCode, string(respBody))
+	}
+
+	var repo struct {
+		FullName string `json:"full_name"`
+	}
+	json.NewDecoder(resp.Body).Decode(&repo)
+	s.CreatedRepo = repo.FullName
+	s.saveState()
+
+	return s.setRepoTopics()
+}
+
+// seedRepo gives a new, empty repository a first commit on branch, an
+// empty .gitkeep written with the contents API. The git data API refuses
+// to write to an empty repository, and pushFiles replaces the commit with
+// a root commit of its own, leaving it out of the project's history.
+func (s *Scaffolder) seedRepo(repo string, branch string) error {
+	if _, err := s.getHeadSHA(repo, branch); err == nil {
+		// seeded by an earlier attempt
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"message": "Seed repository",
+		"content": "",
+		"branch":  branch,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT",
+		fmt.Sprintf("https://api.github.com/repos/%s/contents/.gitkeep", repo),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to seed %s: %d %s", repo, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+func (s *Scaffolder) setRepoTopics() error {
+	if len(s.Config.Repository.Topics) == 0 {
+		return nil
+	}
+	return s.setTopics(s.CreatedRepo, s.Config.Repository.Topics)
+}
+
+// setTopics replaces the topics of repo. GitHub only accepts lowercase
+// topics.
+func (s *Scaffolder) setTopics(repo string, topics []string) error {
+	names := make([]string, len(topics))
+	for i, topic := range topics {
+		names[i] = strings.ToLower(strings.TrimSpace(topic))
+	}
+	body, err := json.Marshal(map[string][]string{"names": names})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT",
+		fmt.Sprintf("https://api.github.com/repos/%s/topics", repo),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to set topics: %d %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// setDefaultBranch makes branch the default branch of repo.
+func (s *Scaffolder) setDefaultBranch(repo string, branch string) error {
+	body, err := json.Marshal(map[string]string{"default_branch": branch})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PATCH", "https://api.github.com/repos/"+repo, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to set default branch %s: %d %s", branch, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// deleteRepo undoes createRepo. Only a repository this scaffold created is
+// deleted, never one that already had the project's name.
+func (s *Scaffolder) deleteRepo() error {
+	if s.CreatedRepo == "" {
+		return nil
+	}
+
+	req, err := http.NewRequest("DELETE", "https://api.github.com/repos/"+s.CreatedRepo, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// tokens from before delete_repo was requested can't delete repos
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("not allowed to delete %s, delete it on GitHub or log in again to grant delete_repo", s.CreatedRepo)
+	}
+
+	// 404 means the repo is already gone
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete repo: %d %s", resp.StatusCode, string(respBody))
+	}
+
+	s.CreatedRepo = ""
+	return nil
+}
+
+// repoFullName is the owner/name of the project's repository.
+func (s *Scaffolder) repoFullName() (string, error) {
+	if s.CreatedRepo != "" {
+		return s.CreatedRepo, nil
+	}
+
+	owner := s.Config.Repository.Owner
+	if owner == "" {
+		username, err := s.getUsername()
+		if err != nil {
+			return "", err
+		}
+		owner = username
+	}
+	return owner + "/" + s.ProjectName, nil
+}
+
+// DefaultBranch is the branch the project is committed on.
+func (s *Scaffolder) DefaultBranch() string {
+	if branch := s.Config.Repository.DefaultBranch; branch != "" {
+		return branch
+	}
+	return "main"
+}
+
+// Names of the repository questions asked after the template's variables.
+const (
+	repoOwner       = "owner"
+	repoVisibility  = "visibility"
+	repoDescription = "description"
+	repoHomepage    = "homepage"
+	repoTopics      = "topics"
+	repoBranch      = "default_branch"
+)
+
+// RepoQuestions asks for the settings of the repository to create,
+// defaulting to the template's.
+func RepoQuestions(cfg github.RepoConfig) []github.Variable {
+	visibility := cfg.Visibility
+	if visibility == "" {
+		visibility = "private"
+	}
+	branch := cfg.DefaultBranch
+	if branch == "" {
+		branch = "main"
+	}
+	return []github.Variable{
+		{Name: repoOwner, Description: "organization to create it in, empty for your account", Default: cfg.Owner},
+		{Name: repoVisibility, Type: github.TypeChoice, Choices: []string{"private", "internal", "public"}, Default: visibility},
+		{Name: repoDescription, Default: cfg.Description},
+		{Name: repoHomepage, Description: "URL shown on the repository page", Default: cfg.Homepage},
+		{Name: repoTopics, Description: "comma separated", Type: "list", Default: strings.Join(cfg.Topics, ", ")},
+		{Name: repoBranch, Description: "branch the project is committed on", Default: branch},
+	}
+}
+
+// ApplyRepoAnswers sets the answers to RepoQuestions on cfg, keeping the
+// template's settings for questions that weren't answered.
+func ApplyRepoAnswers(cfg *github.RepoConfig, answers map[string]string) {
+	if v, ok := answers[repoOwner]; ok {
+		cfg.Owner = strings.TrimSpace(v)
+	}
+	if v, ok := answers[repoVisibility]; ok {
+		cfg.Visibility = v
+	}
+	if v, ok := answers[repoDescription]; ok {
+		cfg.Description = v
+	}
+	if v, ok := answers[repoHomepage]; ok {
+		cfg.Homepage = strings.TrimSpace(v)
+	}
+	if v, ok := answers[repoBranch]; ok {
+		cfg.DefaultBranch = strings.TrimSpace(v)
+	}
+
+	if v, ok := answers[repoTopics]; ok {
+		cfg.Topics = nil
+		for _, topic := range strings.Split(v, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				cfg.Topics = append(cfg.Topics, topic)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// tmplSuffix marks files that are rendered when TemplateConfig.TmplSuffix
+// is set. The suffix is stripped from the generated file.
+const tmplSuffix = ".tmpl"
+
+// matchGlob reports whether a slash-separated path matches pattern. "**"
+// matches any number of directories, and a pattern without a "/" matches the
+// file name at any depth, so "*.md" matches "docs/intro.md". A leading "/"
+// anchors the pattern to the skeleton root.
+func matchGlob(pattern string, name string) bool {
+	if strings.HasPrefix(pattern, "/") {
+		pattern = pattern[1:]
+	} else if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary uses git's heuristic: a NUL byte in the first 8000 bytes.
+func isBinary(file string) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 8000)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return bytes.IndexByte(buf[:n], 0) >= 0, nil
+}
+
+// shouldRender decides whether a skeleton file is rendered as a template or
+// copied verbatim. relPath is the path in the skeleton, before rendering.
+// copy_only always wins; with tmpl_suffix only .tmpl files are rendered;
+// otherwise a non-empty render list limits rendering to matching files.
+// Binary files are never rendered.
+func (s *Scaffolder) shouldRender(relPath string, file string) (bool, error) {
+	if matchAny(s.Config.CopyOnly, relPath) {
+		return false, nil
+	}
+	if s.Config.TmplSuffix {
+		if !strings.HasSuffix(relPath, tmplSuffix) {
+			return false, nil
+		}
+	} else if len(s.Config.Render) > 0 && !matchAny(s.Config.Render, relPath) {
+		return false, nil
+	}
+
+	binary, err := isBinary(file)
+	if err != nil {
+		return false, err
+	}
+	return !binary, nil
+}
+
+// excluded returns the exclude patterns whose conditions hold for the
+// answered variables.
+func (s *Scaffolder) excluded(r *renderer) ([]string, error) {
+	var patterns []string
+	for _, rule := range s.Config.Exclude {
+		if rule.When != "" {
+			ok, err := r.eval(rule.When)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		patterns = append(patterns, rule.Paths...)
+	}
+	return patterns, nil
+}
+
+// delimiters returns the template delimiters for a skeleton file: the first
+// matching delimiter_overrides entry, else the template-wide delimiters.
+// nil means the default {{ }}.
+func (s *Scaffolder) delimiters(relPath string) []string {
+	for _, rule := range s.Config.DelimiterOverrides {
+		if matchAny(rule.Paths, relPath) {
+			return rule.Delimiters
+		}
+	}
+	return s.Config.Delimiters
+}
+
+func (s *Scaffolder) validateDelimiters() error {
+	check := func(delims []string, where string) error {
+		if delims == nil {
+			return nil
+		}
+		if len(delims) != 2 || delims[0] == "" || delims[1] == "" {
+			return fmt.Errorf("%s must be a pair of non-empty strings, got %q", where, delims)
+		}
+		return nil
+	}
+
+	if err := check(s.Config.Delimiters, "delimiters"); err != nil {
+		return err
+	}
+	for _, rule := range s.Config.DelimiterOverrides {
+		if err := check(rule.Delimiters, fmt.Sprintf("delimiters for %v", rule.Paths)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
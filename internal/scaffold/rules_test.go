@@ -0,0 +1,235 @@
+package scaffold
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.md", "README.md", true},
+		{"*.md", "docs/intro.md", true},
+		{"*.md", "docs/intro.txt", false},
+		{"/*.md", "README.md", true},
+		{"/*.md", "docs/intro.md", false},
+		{"docs/*.md", "docs/intro.md", true},
+		{"docs/*.md", "docs/guide/intro.md", false},
+		{"docs/**/*.md", "docs/intro.md", true},
+		{"docs/**/*.md", "docs/guide/deep/intro.md", true},
+		{"docs/**", "docs/guide/intro.md", true},
+		{"docs/**", "other/intro.md", false},
+		{"**/vendor/**", "a/vendor/b/c.go", true},
+		{"assets", "web/assets", true},
+		{"assets", "web/assets/logo.png", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"empty", nil, false},
+		{"text", []byte("package main\n"), false},
+		{"utf-8", []byte("héllo wörld\n"), false},
+		{"nul byte", []byte("PNG\x00\x01\x02"), true},
+		{"nul after the first 8000 bytes", append(bytes.Repeat([]byte("a"), 8000), 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := isBinary(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isBinary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		config github.TemplateConfig
+		path   string
+		binary bool
+		want   bool
+	}{
+		{"renders everything by default", github.TemplateConfig{}, "main.go", false, true},
+		{"binary never renders", github.TemplateConfig{}, "logo.png", true, false},
+		{"render list matches", github.TemplateConfig{Render: []string{"*.go"}}, "cmd/main.go", false, true},
+		{"render list misses", github.TemplateConfig{Render: []string{"*.go"}}, "README.md", false, false},
+		{"copy_only wins over render", github.TemplateConfig{Render: []string{"*.go"}, CopyOnly: []string{"vendor/**"}}, "vendor/x/x.go", false, false},
+		{"copy_only without render", github.TemplateConfig{CopyOnly: []string{"*.tpl"}}, "chart/deploy.tpl", false, false},
+		{"tmpl_suffix renders .tmpl", github.TemplateConfig{TmplSuffix: true}, "main.go.tmpl", false, true},
+		{"tmpl_suffix copies the rest", github.TemplateConfig{TmplSuffix: true}, "main.go", false, false},
+		{"tmpl_suffix ignores render", github.TemplateConfig{TmplSuffix: true, Render: []string{"*.go"}}, "main.go", false, false},
+		{"binary .tmpl", github.TemplateConfig{TmplSuffix: true}, "logo.png.tmpl", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte("hello {{project_name}}\n")
+			if tt.binary {
+				data = []byte("\x89PNG\x00\x00")
+			}
+			file := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(file, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s := &Scaffolder{Config: tt.config}
+			got, err := s.shouldRender(tt.path, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("shouldRender(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDelimiters(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  github.TemplateConfig
+		wantErr bool
+	}{
+		{"default", github.TemplateConfig{}, false},
+		{"pair", github.TemplateConfig{Delimiters: []string{"[[", "]]"}}, false},
+		{"one delimiter", github.TemplateConfig{Delimiters: []string{"[["}}, true},
+		{"three delimiters", github.TemplateConfig{Delimiters: []string{"[[", "]]", "}}"}}, true},
+		{"empty left", github.TemplateConfig{Delimiters: []string{"", "]]"}}, true},
+		{"empty right", github.TemplateConfig{Delimiters: []string{"[[", ""}}, true},
+		{"valid override", github.TemplateConfig{DelimiterOverrides: []github.DelimiterRule{
+			{Paths: []string{"charts/**"}, Delimiters: []string{"<%", "%>"}},
+		}}, false},
+		{"invalid override", github.TemplateConfig{DelimiterOverrides: []github.DelimiterRule{
+			{Paths: []string{"charts/**"}, Delimiters: []string{"<%"}},
+		}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scaffolder{Config: tt.config}
+			if err := s.validateDelimiters(); (err != nil) != tt.wantErr {
+				t.Errorf("validateDelimiters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDelimiters(t *testing.T) {
+	s := &Scaffolder{Config: github.TemplateConfig{
+		Delimiters: []string{"[[", "]]"},
+		DelimiterOverrides: []github.DelimiterRule{
+			{Paths: []string{"charts/**"}, Delimiters: []string{"<%", "%>"}},
+			{Paths: []string{"charts/values.yaml", "*.j2"}, Delimiters: []string{"((", "))"}},
+		},
+	}}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"[[", "]]"}},
+		{"charts/templates/deploy.yaml", []string{"<%", "%>"}},
+		{"charts/values.yaml", []string{"<%", "%>"}},
+		{"ansible/site.j2", []string{"((", "))"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := s.delimiters(tt.path); !slices.Equal(got, tt.want) {
+				t.Errorf("delimiters(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	vars := []github.Variable{{Name: "include_helm", Type: "bool"}, {Name: "language"}, {Name: "ci"}}
+	exclude := []github.ExcludeRule{
+		{Paths: []string{"*.bak"}},
+		{Paths: []string{"charts/**"}, When: "not include_helm"},
+		{Paths: []string{"go.mod", "cmd/**"}, When: `ne language "go"`},
+		{Paths: []string{".github/**"}, When: `eq ci "no"`},
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "nothing conditional holds",
+			values: map[string]string{"include_helm": "yes", "language": "go", "ci": "github"},
+			want:   []string{"*.bak"},
+		},
+		{
+			name:   "bool answered no",
+			values: map[string]string{"include_helm": "no", "language": "go", "ci": "github"},
+			want:   []string{"*.bak", "charts/**"},
+		},
+		{
+			name:   "string compared as typed",
+			values: map[string]string{"include_helm": "yes", "language": "go", "ci": "no"},
+			want:   []string{"*.bak", ".github/**"},
+		},
+		{
+			name:   "every condition holds",
+			values: map[string]string{"include_helm": "false", "language": "rust", "ci": "no"},
+			want:   []string{"*.bak", "charts/**", "go.mod", "cmd/**", ".github/**"},
+		},
+		{
+			name:    "unanswered variable",
+			values:  map[string]string{"include_helm": "yes", "ci": "no"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRenderer(vars, tt.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &Scaffolder{Config: github.TemplateConfig{Exclude: exclude}}
+			got, err := s.excluded(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("excluded() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("excluded() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
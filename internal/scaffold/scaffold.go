@@ -1,142 +1,301 @@
 package scaffold
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/kickstartdev/kickstart/internal/github"
 )
 
 type Step struct {
 	Name string
 	Fn   func() error
+	// Output returns what the step printed, for steps that run commands
+	Output func() string
+	// Undo reverts the step's side effects when scaffolding is rolled back.
+	// It must tolerate the step having failed part way.
+	Undo func() error
 }
 
+// Mode selects what happens to the rendered project.
+type Mode string
+
+const (
+	// ModeGitHub creates a GitHub repository, pushes the project and clones it
+	ModeGitHub Mode = "github"
+	// ModeDryRun only renders the project locally and reports on it
+	ModeDryRun Mode = "dry-run"
+	// ModeLocal initializes a local git repository instead of using GitHub
+	ModeLocal Mode = "local"
+	// ModePullRequest adds the project to a subdirectory of an existing
+	// repository through a pull request
+	ModePullRequest Mode = "pull-request"
+)
+
 type Scaffolder struct {
 	Token      string
 	Owner      string
 	Repo       string
+	// Branch is the template ref asked for, empty for the default branch
 	Branch     string
 	ProjectName string
 	Variables  map[string]string
+	Config     github.TemplateConfig
+	Source     Source
 	OutputDir  string
+	// RunHooks enables the template's post_create hooks
+	RunHooks   bool
+	Mode       Mode
+	// Remote is added as origin in local mode, if set
+	Remote     string
+	// Target is the owner/name of the repository the project is added to
+	// in pull request mode, TargetDir the directory it goes in and
+	// TargetBranch the branch the pull request is opened from
+	Target       string
+	TargetDir    string
+	TargetBranch string
+	// TargetBase is the branch the pull request targets, Target's default
+	TargetBase   string
+	// PullRequest is the URL of the opened pull request
+	PullRequest  string
+	// Report is filled in by a dry run
+	Report     *Report
+	// Progress is called as files are pushed, if set
+	Progress   func(done int, total int, path string)
+
+	// Revision is the template commit the project was rendered from
+	Revision    string
+	// Variant is the chosen variant of the template, if it has any
+	Variant     string
+	// Bases are the commits of the templates it extends or includes, see
+	// github.Template
+	Bases       map[string]string
+
+	// Completed is the number of steps that finished, see State
+	Completed   int
+	CreatedRepo string
+	CreatedBranch string
+
+	mu         sync.Mutex // guards blobs and state saves during uploads
+	blobs      map[string]string
+	hookOutput map[int]string
+	sources    map[string]string // skeleton path of each rendered path
 }
 
-func New(token, owner, repo, branch, projectName string, variables map[string]string) *Scaffolder {
+func New(token string, tmpl github.Template, projectName string, variables map[string]string) *Scaffolder {
+	branch := tmpl.Ref
 	if branch == "" {
-		branch = "main"
+		branch = tmpl.Config.Branch
 	}
+	bases := make(map[string]string)
+	maps.Copy(bases, tmpl.Bases)
 	return &Scaffolder{
+		Source:      SourceFor(token, tmpl),
 		Token:       token,
-		Owner:       owner,
-		Repo:        repo,
+		Owner:       tmpl.Owner,
+		Repo:        tmpl.Repo,
 		Branch:      branch,
+		Revision:    tmpl.Revision,
+		Variant:     variables[VariantVariable],
+		Bases:       bases,
 		ProjectName: projectName,
 		Variables:   variables,
+		Config:      tmpl.Config,
 		OutputDir:   filepath.Join(".", projectName),
+		Mode:        ModeGitHub,
+		blobs:       make(map[string]string),
 	}
 }
 
 func (s *Scaffolder) Steps() []Step {
-	return []Step{
-		{Name: "Downloading skeleton", Fn: s.downloadSkeleton},
-		{Name: "Replacing variables", Fn: s.replaceVariables},
-		{Name: "Creating GitHub repository", Fn: s.createRepo},
-		{Name: "Pushing files", Fn: s.pushFiles},
-		{Name: "Cloning locally", Fn: s.cloneRepo},
+	steps := []Step{
+		{Name: "Downloading skeleton", Fn: s.downloadSkeleton, Undo: s.removeOutput},
+		{Name: "Rendering templates", Fn: s.replaceVariables},
+	}
+
+	switch s.Mode {
+	case ModeDryRun:
+		// nothing leaves the machine, so there is nothing to resume either
+		return append(steps, Step{Name: "Inspecting output", Fn: s.inspect})
+	case ModeLocal:
+		steps = append(steps, Step{Name: "Initializing git repository", Fn: s.initLocalRepo})
+	case ModePullRequest:
+		steps = append(steps,
+			Step{Name: "Pushing branch " + s.TargetBranch, Fn: s.pushBranch, Undo: s.deleteBranch},
+			Step{Name: "Opening pull request", Fn: s.openPullRequest},
+		)
+	default:
+		steps = append(steps,
+			Step{Name: "Creating GitHub repository", Fn: s.createRepo, Undo: s.deleteRepo},
+			Step{Name: "Pushing files", Fn: s.pushFiles},
+			Step{Name: "Cloning locally", Fn: s.cloneRepo},
+		)
+	}
+	if s.Mode != ModePullRequest {
+		// hooks run in a clone, and nothing is cloned for a pull request
+		steps = append(steps, s.hookSteps()...)
+	}
+
+	// record progress after every step so the run can be resumed
+	for i := range steps {
+		fn := steps[i].Fn
+		steps[i].Fn = func() error {
+			if err := fn(); err != nil {
+				return err
+			}
+			s.Completed = i + 1
+			if s.Completed == len(steps) {
+				clearState()
+			} else {
+				s.saveState()
+			}
+			return nil
+		}
 	}
+
+	return steps
 }
 
-// Step 1: Download skeleton/ folder from the template repo
-func (s *Scaffolder) downloadSkeleton() error {
-	return s.downloadDir("skeleton", s.OutputDir)
+// Rollback undoes steps 0 through failed in reverse order, so a failed
+// scaffold leaves neither a half-initialized repo nor a stray directory.
+func (s *Scaffolder) Rollback(failed int) error {
+	steps := s.Steps()
+	var errs []error
+	for i := failed; i >= 0; i-- {
+		if steps[i].Undo == nil {
+			continue
+		}
+		if err := steps[i].Undo(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", steps[i].Name, err))
+		}
+	}
+	clearState()
+	return errors.Join(errs...)
 }
 
-func (s *Scaffolder) downloadDir(remotePath string, localPath string) error {
-	req, err := http.NewRequest("GET",
-		fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s", s.Owner, s.Repo, remotePath, s.Branch),
-		nil,
-	)
+func (s *Scaffolder) removeOutput() error {
+	os.RemoveAll(s.OutputDir + ".rendering")
+	return os.RemoveAll(s.OutputDir)
+}
+
+// Step 1: Download skeleton/ folder from the template source, without the
+// files the template asks to ignore
+func (s *Scaffolder) downloadSkeleton() error {
+	revision, err := s.Source.Revision()
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	s.Revision = revision
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
+	if err := s.fetchLayers(); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	return s.applyIgnore()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to list %s: status %d", remotePath, resp.StatusCode)
+// Step 2: Render every file and path in the skeleton, leaving out paths
+// excluded by template.yaml. The result is built in a staging directory and
+// swapped in, so renamed and dropped paths never mix with the originals.
+func (s *Scaffolder) replaceVariables() error {
+	if err := s.validateDelimiters(); err != nil {
+		return err
 	}
 
-	var contents []struct {
-		Name        string `json:"name"`
-		Path        string `json:"path"`
-		Type        string `json:"type"`
-		DownloadURL string `json:"download_url"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&contents); err != nil {
+	r, err := newRenderer(s.Config.Variables, s.Variables)
+	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(localPath, 0755); err != nil {
+	excluded, err := s.excluded(r)
+	if err != nil {
 		return err
 	}
 
-	for _, item := range contents {
-		localItemPath := filepath.Join(localPath, item.Name)
+	staging := s.OutputDir + ".rendering"
+	os.RemoveAll(staging)
 
-		if item.Type == "dir" {
-			if err := s.downloadDir(item.Path, localItemPath); err != nil {
-				return err
+	rendered := make(map[string]string)
+	err = filepath.Walk(s.OutputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, _ := filepath.Rel(s.OutputDir, path)
+		if relPath == "." {
+			return os.MkdirAll(staging, 0755)
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAny(excluded, relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
-		} else {
-			if err := s.downloadFile(item.DownloadURL, localItemPath); err != nil {
-				return err
+			return nil
+		}
+
+		// a path renders with the same delimiters as the file's contents
+		dest, err := r.renderPath(relPath, s.delimiters(relPath))
+		if err != nil {
+			return err
+		}
+		if dest == "" {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
 		}
-	}
 
-	return nil
-}
+		// nothing may be written through a symlink rendered earlier
+		if err := insideLink(relPath, dest, rendered); err != nil {
+			return err
+		}
 
-func (s *Scaffolder) downloadFile(url string, dest string) error {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+s.Token)
+		if info.IsDir() {
+			if other, ok := rendered[dest]; ok {
+				return fmt.Errorf("%s and %s both render to %s", other, relPath, dest)
+			}
+			return os.MkdirAll(filepath.Join(staging, filepath.FromSlash(dest)), 0755)
+		}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+		// symlinks are recreated with their target rendered like a path
+		if info.Mode()&os.ModeSymlink != 0 {
+			return s.renderSymlink(r, relPath, path, staging, dest, rendered)
+		}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+		render, err := s.shouldRender(relPath, path)
+		if err != nil {
+			return err
+		}
+		if s.Config.TmplSuffix && strings.HasSuffix(dest, tmplSuffix) {
+			dest = strings.TrimSuffix(dest, tmplSuffix)
+			if strings.HasSuffix(dest, "/") || dest == "" {
+				return nil
+			}
+		}
+		target := filepath.Join(staging, filepath.FromSlash(dest))
 
-	return os.WriteFile(dest, data, 0644)
-}
+		if other, ok := rendered[dest]; ok {
+			return fmt.Errorf("%s and %s both render to %s", other, relPath, dest)
+		}
+		rendered[dest] = relPath
 
-// Step 2: Walk through all files and replace {{variable}} placeholders
-func (s *Scaffolder) replaceVariables() error {
-	return filepath.Walk(s.OutputDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
 			return err
 		}
-		if info.IsDir() {
-			return nil
+
+		// files that aren't templates are carried over untouched
+		if !render {
+			return linkOrCopy(path, target, info.Mode().Perm())
 		}
 
 		data, err := os.ReadFile(path)
@@ -144,115 +303,184 @@ func (s *Scaffolder) replaceVariables() error {
 			return err
 		}
 
-		content := string(data)
-		for key, value := range s.Variables {
-			content = strings.ReplaceAll(content, "{{"+key+"}}", value)
+		content, err := r.render(relPath, string(data), s.delimiters(relPath))
+		if err != nil {
+			return err
 		}
 
-		return os.WriteFile(path, []byte(content), 0644)
+		return os.WriteFile(target, []byte(content), info.Mode().Perm())
 	})
+	if err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	s.sources = rendered
+
+	if err := os.RemoveAll(s.OutputDir); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, s.OutputDir); err != nil {
+		return err
+	}
+	return s.writeProvenance()
 }
 
-// Step 3: Create a new GitHub repo
-func (s *Scaffolder) createRepo() error {
-	body := fmt.Sprintf(`{"name":"%s","private":true,"auto_init":true}`, s.ProjectName)
+func (s *Scaffolder) renderSymlink(r *renderer, relPath string, path string, staging string, dest string, rendered map[string]string) error {
+	if other, ok := rendered[dest]; ok {
+		return fmt.Errorf("%s and %s both render to %s", other, relPath, dest)
+	}
+	rendered[dest] = relPath
 
-	req, err := http.NewRequest("POST", "https://api.github.com/user/repos", strings.NewReader(body))
+	target, err := os.Readlink(path)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := http.DefaultClient.Do(req)
+	target, err = r.render(relPath, target, s.Config.Delimiters)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	if !linkInside(dest, target) {
+		return &RenderError{File: relPath, Msg: fmt.Sprintf("symlink target %s points outside the project", target)}
+	}
 
-	if resp.StatusCode != http.StatusCreated {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to create repo: %d %s", resp.StatusCode, string(respBody))
+	link := filepath.Join(staging, filepath.FromSlash(dest))
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		return err
 	}
+	return os.Symlink(target, link)
+}
 
+// insideLink refuses dest when one of its parent directories is a path
+// already rendered, which can only be a symlink or a file.
+func insideLink(relPath string, dest string, rendered map[string]string) error {
+	for i := range dest {
+		if dest[i] != '/' {
+			continue
+		}
+		if other, ok := rendered[dest[:i]]; ok {
+			return fmt.Errorf("%s renders to %s, inside %s from %s", relPath, dest, dest[:i], other)
+		}
+	}
 	return nil
 }
 
 // Step 4: Push files to the new repo using GitHub's Git API
 func (s *Scaffolder) pushFiles() error {
-	// get the username from the token
-	username, err := s.getUsername()
+	repo, err := s.repoFullName()
 	if err != nil {
 		return err
 	}
 
-	// collect all files
-	var files []fileEntry
-	err = filepath.Walk(s.OutputDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
+	files, err := s.collectFiles("")
+	if err != nil {
+		return err
+	}
 
-		relPath, _ := filepath.Rel(s.OutputDir, path)
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
+	branch := s.DefaultBranch()
+	if err := s.seedRepo(repo, branch); err != nil {
+		return err
+	}
 
-		files = append(files, fileEntry{
-			Path:    relPath,
-			Content: base64.StdEncoding.EncodeToString(data),
-		})
-		return nil
-	})
+	// upload what can't be inlined into the tree
+	treeEntries, err := s.buildTree(repo, files)
 	if err != nil {
 		return err
 	}
 
-	// create blobs
-	var treeEntries []map[string]string
-	for _, f := range files {
-		sha, err := s.createBlob(username, f.Content)
-		if err != nil {
-			return fmt.Errorf("blob for %s: %w", f.Path, err)
-		}
-		treeEntries = append(treeEntries, map[string]string{
-			"path": f.Path,
-			"mode": "100644",
-			"type": "blob",
-			"sha":  sha,
-		})
+	// create tree
+	treeSHA, err := s.createTree(repo, treeEntries, "")
+	if err != nil {
+		return err
 	}
 
-	// create tree
-	treeSHA, err := s.createTree(username, treeEntries)
+	message, err := s.commitMessage("Initial scaffold from " + s.Repo)
 	if err != nil {
 		return err
 	}
 
-	// get the SHA of the initial commit created by auto_init
-	parentSHA, err := s.getHeadSHA(username)
+	// a root commit, so the seed commit isn't part of the history
+	commitSHA, err := s.createCommit(repo, treeSHA, message, "")
 	if err != nil {
 		return err
 	}
 
-	// create commit on top of the auto_init commit
-	commitSHA, err := s.createCommit(username, treeSHA, "Initial scaffold from "+s.Repo, parentSHA)
+	// point the default branch at our commit, replacing the repository's
+	// default branch when it has another name
+	initial, err := s.getDefaultBranch(repo)
 	if err != nil {
 		return err
 	}
+	err = s.updateRef(repo, branch, commitSHA)
+	if errors.Is(err, errNoRef) {
+		err = s.createRef(repo, branch, commitSHA)
+	}
+	if err != nil || initial == branch {
+		return err
+	}
+	if err := s.setDefaultBranch(repo, branch); err != nil {
+		return err
+	}
+	return s.deleteRef(repo, initial)
+}
 
-	// update main branch to point to our commit
-	return s.updateRef(username, commitSHA)
+// collectFiles lists the rendered project for pushing, with every path
+// below prefix if it isn't empty.
+func (s *Scaffolder) collectFiles(prefix string) ([]fileEntry, error) {
+	var files []fileEntry
+	ignore := parseIgnore(s.Config.Ignore)
+	err := filepath.Walk(s.OutputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(s.OutputDir, path)
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath != "." && ignored(ignore, relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		repoPath := relPath
+		if prefix != "" {
+			repoPath = strings.Trim(prefix, "/") + "/" + relPath
+		}
+
+		// git can't store empty directories, keep them with a placeholder
+		if info.IsDir() {
+			children, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			if len(children) == 0 && relPath != "." {
+				files = append(files, fileEntry{Path: repoPath + "/.gitkeep", Mode: modeFile, SHA: gitBlobSHA(nil)})
+			}
+			return nil
+		}
+
+		data, mode, err := readEntry(path, info)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, fileEntry{
+			Path: repoPath,
+			Data: data,
+			Mode: mode,
+			SHA:  gitBlobSHA(data),
+		})
+		return nil
+	})
+	return files, err
 }
 
 type fileEntry struct {
-	Path    string
-	Content string // base64
+	Path string
+	Data []byte
+	Mode string // git file mode, e.g. 100644
+	SHA  string // git blob SHA of the content
 }
 
 func (s *Scaffolder) getUsername() (string, error) {
@@ -260,7 +488,7 @@ func (s *Scaffolder) getUsername() (string, error) {
 	req.Header.Set("Authorization", "Bearer "+s.Token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -273,16 +501,19 @@ func (s *Scaffolder) getUsername() (string, error) {
 	return user.Login, nil
 }
 
-func (s *Scaffolder) createBlob(username string, content string) (string, error) {
-	body := fmt.Sprintf(`{"content":"%s","encoding":"base64"}`, content)
+func (s *Scaffolder) createBlob(repo string, data []byte) (string, error) {
+	body, err := json.Marshal(map[string]string{"content": base64.StdEncoding.EncodeToString(data), "encoding": "base64"})
+	if err != nil {
+		return "", err
+	}
 	req, _ := http.NewRequest("POST",
-		fmt.Sprintf("https://api.github.com/repos/%s/%s/git/blobs", username, s.ProjectName),
-		strings.NewReader(body),
+		fmt.Sprintf("https://api.github.com/repos/%s/git/blobs", repo),
+		bytes.NewReader(body),
 	)
 	req.Header.Set("Authorization", "Bearer "+s.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -300,18 +531,26 @@ func (s *Scaffolder) createBlob(username string, content string) (string, error)
 	return result.SHA, nil
 }
 
-func (s *Scaffolder) createTree(username string, entries []map[string]string) (string, error) {
-	entriesJSON, _ := json.Marshal(entries)
-	body := fmt.Sprintf(`{"tree":%s}`, string(entriesJSON))
+// createTree creates a tree from entries, on top of baseTree if it isn't
+// empty.
+func (s *Scaffolder) createTree(repo string, entries []treeEntry, baseTree string) (string, error) {
+	payload := map[string]any{"tree": entries}
+	if baseTree != "" {
+		payload["base_tree"] = baseTree
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
 
 	req, _ := http.NewRequest("POST",
-		fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees", username, s.ProjectName),
-		strings.NewReader(body),
+		fmt.Sprintf("https://api.github.com/repos/%s/git/trees", repo),
+		bytes.NewReader(body),
 	)
 	req.Header.Set("Authorization", "Bearer "+s.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -329,15 +568,15 @@ func (s *Scaffolder) createTree(username string, entries []map[string]string) (s
 	return result.SHA, nil
 }
 
-func (s *Scaffolder) getHeadSHA(username string) (string, error) {
+func (s *Scaffolder) getHeadSHA(repo string, branch string) (string, error) {
 	req, _ := http.NewRequest("GET",
-		fmt.Sprintf("https://api.github.com/repos/%s/%s/git/ref/heads/main", username, s.ProjectName),
+		fmt.Sprintf("https://api.github.com/repos/%s/git/ref/heads/%s", repo, branch),
 		nil,
 	)
 	req.Header.Set("Authorization", "Bearer "+s.Token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -357,17 +596,24 @@ func (s *Scaffolder) getHeadSHA(username string) (string, error) {
 	return result.Object.SHA, nil
 }
 
-func (s *Scaffolder) updateRef(username string, commitSHA string) error {
-	body := fmt.Sprintf(`{"sha":"%s"}`, commitSHA)
+// errNoRef is returned by updateRef when the branch doesn't exist.
+var errNoRef = errors.New("no such branch")
+
+// updateRef force-moves branch to commitSHA.
+func (s *Scaffolder) updateRef(repo string, branch string, commitSHA string) error {
+	body, err := json.Marshal(map[string]any{"sha": commitSHA, "force": true})
+	if err != nil {
+		return err
+	}
 
 	req, _ := http.NewRequest("PATCH",
-		fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/heads/main", username, s.ProjectName),
-		strings.NewReader(body),
+		fmt.Sprintf("https://api.github.com/repos/%s/git/refs/heads/%s", repo, branch),
+		bytes.NewReader(body),
 	)
 	req.Header.Set("Authorization", "Bearer "+s.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
@@ -375,23 +621,40 @@ func (s *Scaffolder) updateRef(username string, commitSHA string) error {
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
+		// rulesets and branch protection refuse with 422 as well
+		var result struct {
+			Message string `json:"message"`
+		}
+		if resp.StatusCode == http.StatusUnprocessableEntity &&
+			json.Unmarshal(respBody, &result) == nil && result.Message == "Reference does not exist" {
+			return errNoRef
+		}
 		return fmt.Errorf("failed to update ref: %d %s", resp.StatusCode, string(respBody))
 	}
 
 	return nil
 }
 
-func (s *Scaffolder) createCommit(username string, treeSHA string, message string, parentSHA string) (string, error) {
-	body := fmt.Sprintf(`{"message":"%s","tree":"%s","parents":["%s"]}`, message, treeSHA, parentSHA)
+// createCommit creates a commit on parentSHA, or a root commit when it is
+// empty, authored and signed as the user's git config says.
+func (s *Scaffolder) createCommit(repo string, treeSHA string, message string, parentSHA string) (string, error) {
+	commit, err := newCommit(treeSHA, parentSHA, message)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(commit)
+	if err != nil {
+		return "", err
+	}
 
 	req, _ := http.NewRequest("POST",
-		fmt.Sprintf("https://api.github.com/repos/%s/%s/git/commits", username, s.ProjectName),
-		strings.NewReader(body),
+		fmt.Sprintf("https://api.github.com/repos/%s/git/commits", repo),
+		bytes.NewReader(body),
 	)
 	req.Header.Set("Authorization", "Bearer "+s.Token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -415,13 +678,13 @@ func (s *Scaffolder) cloneRepo() error {
 	// remove the skeleton we downloaded
 	os.RemoveAll(s.OutputDir)
 
-	username, err := s.getUsername()
+	repo, err := s.repoFullName()
 	if err != nil {
 		return err
 	}
 
 	// use git clone with the token embedded
-	cloneURL := fmt.Sprintf("https://%s@github.com/%s/%s.git", s.Token, username, s.ProjectName)
+	cloneURL := fmt.Sprintf("https://%s@github.com/%s.git", s.Token, repo)
 
 	cmd := execCommand("git", "clone", cloneURL, s.OutputDir)
 	output, err := cmd.CombinedOutput()
@@ -0,0 +1,79 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// Source is where a template comes from: a GitHub repository or a local
+// directory, both holding template.yaml next to a skeleton/ directory.
+type Source interface {
+	// Name identifies the template, e.g. "owner/repo" or a local path
+	Name() string
+	// Config reads the template's template.yaml
+	Config() (*github.TemplateConfig, error)
+	// Fetch copies the directory dir of the template (e.g. "skeleton") into
+	// dest, keeping file modes and symlinks
+	Fetch(dir string, dest string) error
+	// Revision resolves the commit SHA of the template, "" if unknown
+	Revision() (string, error)
+}
+
+// SourceFor returns the source of tmpl: its local directory if it has a
+// Path, otherwise its GitHub repository.
+func SourceFor(token string, tmpl github.Template) Source {
+	if tmpl.Path != "" {
+		return &LocalSource{Dir: tmpl.Path}
+	}
+
+	// HEAD is whatever the repository's default branch is
+	ref := tmpl.Revision
+	if ref == "" {
+		ref = tmpl.Ref
+	}
+	if ref == "" {
+		ref = tmpl.Config.Branch
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return &GitHubSource{Token: token, Owner: tmpl.Owner, Repo: tmpl.Repo, Ref: ref}
+}
+
+// LocalTemplate loads a template from a local directory, given as a path
+// or a file:// URL. token reads the GitHub templates it extends or
+// includes.
+func LocalTemplate(token string, location string) (github.Template, error) {
+	dir, err := filepath.Abs(strings.TrimPrefix(location, "file://"))
+	if err != nil {
+		return github.Template{}, err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return github.Template{}, err
+	}
+	if !info.IsDir() {
+		return github.Template{}, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	bases := make(map[string]string)
+	cfg, _, err := compose(token, &LocalSource{Dir: dir}, nil, bases)
+	if err != nil {
+		return github.Template{}, err
+	}
+	if err := validateVariables(cfg.Variables); err != nil {
+		return github.Template{}, fmt.Errorf("template.yaml in %s: %w", dir, err)
+	}
+
+	return github.Template{
+		Config: *cfg,
+		Repo:   filepath.Base(dir),
+		Path:   dir,
+		Bases:  bases,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+var fullSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// GitHubSource reads a template from a GitHub repository at Ref.
+type GitHubSource struct {
+	Token string
+	Owner string
+	Repo  string
+	Ref   string
+
+	tarball []byte
+}
+
+func (s *GitHubSource) Name() string {
+	return s.Owner + "/" + s.Repo
+}
+
+func (s *GitHubSource) Config() (*github.TemplateConfig, error) {
+	return github.GetTemplateConfig(s.Token, s.Owner, s.Repo, s.Ref)
+}
+
+// Revision resolves Ref to a commit SHA and pins the source to it, so
+// everything fetched afterwards comes from the same commit.
+func (s *GitHubSource) Revision() (string, error) {
+	if fullSHA.MatchString(s.Ref) {
+		return s.Ref, nil
+	}
+
+	req, err := http.NewRequest("GET",
+		fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", s.Owner, s.Repo, s.Ref),
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Accept", "application/vnd.github.sha")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to resolve %s@%s: status %d", s.Name(), s.Ref, resp.StatusCode)
+	}
+
+	sha, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	s.Ref = strings.TrimSpace(string(sha))
+	s.tarball = nil
+	return s.Ref, nil
+}
+
+// Fetch downloads the repository as a single tarball and extracts dir
+// from it, instead of listing and downloading every file separately.
+func (s *GitHubSource) Fetch(dir string, dest string) error {
+	if s.tarball == nil {
+		data, err := s.downloadTarball()
+		if err != nil {
+			return err
+		}
+		s.tarball = data
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(s.tarball))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	return extractTar(tar.NewReader(gz), dir, dest)
+}
+
+func (s *GitHubSource) downloadTarball() ([]byte, error) {
+	req, err := http.NewRequest("GET",
+		fmt.Sprintf("https://api.github.com/repos/%s/%s/tarball/%s", s.Owner, s.Repo, s.Ref),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	// the API redirects to codeload.github.com with a short-lived token
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s@%s: status %d", s.Name(), s.Ref, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,66 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// LocalSource reads a template from a directory on disk, so template
+// authors can try changes without pushing them.
+type LocalSource struct {
+	Dir string
+}
+
+func (s *LocalSource) Name() string {
+	return s.Dir
+}
+
+func (s *LocalSource) Config() (*github.TemplateConfig, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, "template.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("template.yaml not found in %s", s.Dir)
+	}
+	return github.ParseTemplateConfig(data, s.Dir)
+}
+
+func (s *LocalSource) Fetch(dir string, dest string) error {
+	root := filepath.Join(s.Dir, filepath.FromSlash(dir))
+	if _, err := os.Stat(root); err != nil {
+		return fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, _ := filepath.Rel(root, path)
+		target := filepath.Join(dest, relPath)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		data, mode, err := readEntry(path, info)
+		if err != nil {
+			return err
+		}
+		return writeEntry(target, mode, data)
+	})
+}
+
+// Revision is the checked out commit if the template directory is a git
+// repository. Uncommitted changes aren't reflected.
+func (s *LocalSource) Revision() (string, error) {
+	cmd := execCommand("git", "rev-parse", "HEAD")
+	cmd.Dir = s.Dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,198 @@
+package scaffold
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kickstartdev/kickstart/internal/debug"
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// State is the progress of a scaffold. It is saved after every step and
+// blob upload, so a run that fails midway can continue with
+// `kickstart resume` instead of starting over.
+type State struct {
+	Owner       string                `json:"owner"`
+	Repo        string                `json:"repo"`
+	Branch      string                `json:"branch"`
+	TemplateDir string                `json:"template_dir,omitempty"`
+	Revision    string                `json:"revision,omitempty"`
+	Variant     string                `json:"variant,omitempty"`
+	Bases       map[string]string     `json:"bases,omitempty"`
+	ProjectName string                `json:"project_name"`
+	Variables   map[string]string     `json:"variables"`
+	Config      github.TemplateConfig `json:"config"`
+	OutputDir   string                `json:"output_dir"`
+	RunHooks    bool                  `json:"run_hooks"`
+	Mode        Mode                  `json:"mode"`
+	Remote      string                `json:"remote,omitempty"`
+
+	Target       string `json:"target,omitempty"`
+	TargetDir    string `json:"target_dir,omitempty"`
+	TargetBranch string `json:"target_branch,omitempty"`
+	TargetBase   string `json:"target_base,omitempty"`
+
+	// Completed is the number of steps that finished
+	Completed int `json:"completed"`
+	// CreatedRepo is the owner/name of the repository created for the project
+	CreatedRepo string `json:"created_repo,omitempty"`
+	// CreatedBranch is the branch pushed in pull request mode
+	CreatedBranch string `json:"created_branch,omitempty"`
+	// Blobs maps file paths to the blob SHAs already uploaded
+	Blobs map[string]string `json:"blobs,omitempty"`
+}
+
+func statePath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kickstart", "state.json")
+}
+
+// LoadState reads the state of the last unfinished scaffold.
+func LoadState() (*State, error) {
+	data, err := os.ReadFile(statePath())
+	if err != nil {
+		return nil, err
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", statePath(), err)
+	}
+	return &st, nil
+}
+
+// MissingSecrets lists the secret variables to ask for again before
+// resuming, as they aren't saved.
+func (st *State) MissingSecrets() []github.Variable {
+	var secrets []github.Variable
+	for _, v := range st.Config.Variables {
+		if _, ok := st.Variables[v.Name]; !ok && v.Type == github.TypeSecret {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
+
+// Resume rebuilds a Scaffolder from a saved state. Steps before
+// st.Completed are not run again.
+func Resume(token string, st *State) *Scaffolder {
+	blobs := st.Blobs
+	if blobs == nil {
+		blobs = make(map[string]string)
+	}
+	bases := st.Bases
+	if bases == nil {
+		bases = make(map[string]string)
+	}
+	tmpl := github.Template{Config: st.Config, Owner: st.Owner, Repo: st.Repo, Path: st.TemplateDir}
+	tmpl.Config.Branch = st.Branch
+	if st.Revision != "" {
+		// pick up the exact commit the run started from
+		tmpl.Config.Branch = st.Revision
+	}
+
+	return &Scaffolder{
+		Source:        SourceFor(token, tmpl),
+		Token:         token,
+		Owner:         st.Owner,
+		Repo:          st.Repo,
+		Branch:        st.Branch,
+		Revision:      st.Revision,
+		Variant:       st.Variant,
+		Bases:         bases,
+		ProjectName:   st.ProjectName,
+		Variables:     st.Variables,
+		Config:        st.Config,
+		OutputDir:     st.OutputDir,
+		RunHooks:      st.RunHooks,
+		Mode:          st.Mode,
+		Remote:        st.Remote,
+		Target:        st.Target,
+		TargetDir:     st.TargetDir,
+		TargetBranch:  st.TargetBranch,
+		TargetBase:    st.TargetBase,
+		Completed:     st.Completed,
+		CreatedRepo:   st.CreatedRepo,
+		CreatedBranch: st.CreatedBranch,
+		blobs:         blobs,
+	}
+}
+
+func (s *Scaffolder) state() State {
+	outputDir, err := filepath.Abs(s.OutputDir)
+	if err != nil {
+		outputDir = s.OutputDir
+	}
+
+	var templateDir string
+	if local, ok := s.Source.(*LocalSource); ok {
+		templateDir = local.Dir
+	}
+
+	// secrets stay in memory, a resumed run asks for them again
+	variables := make(map[string]string, len(s.Variables))
+	for name, value := range s.Variables {
+		variables[name] = value
+	}
+	for _, v := range s.Config.Variables {
+		if v.Type == github.TypeSecret {
+			delete(variables, v.Name)
+		}
+	}
+
+	return State{
+		Owner:         s.Owner,
+		Repo:          s.Repo,
+		Branch:        s.Branch,
+		TemplateDir:   templateDir,
+		Revision:      s.Revision,
+		Variant:       s.Variant,
+		Bases:         s.Bases,
+		ProjectName:   s.ProjectName,
+		Variables:     variables,
+		Config:        s.Config,
+		OutputDir:     outputDir,
+		RunHooks:      s.RunHooks,
+		Mode:          s.Mode,
+		Remote:        s.Remote,
+		Target:        s.Target,
+		TargetDir:     s.TargetDir,
+		TargetBranch:  s.TargetBranch,
+		TargetBase:    s.TargetBase,
+		Completed:     s.Completed,
+		CreatedRepo:   s.CreatedRepo,
+		CreatedBranch: s.CreatedBranch,
+		Blobs:         s.blobs,
+	}
+}
+
+// saveState persists progress. Failing to save only costs the ability to
+// resume, so errors are logged rather than failing the step.
+func (s *Scaffolder) saveState() {
+	data, err := json.Marshal(s.state())
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(statePath()), 0700); err == nil {
+			err = os.WriteFile(statePath(), data, 0600)
+		}
+	}
+	if err != nil {
+		debug.Log("saveState: %v", err)
+	}
+}
+
+func clearState() {
+	os.Remove(statePath())
+}
+
+// gitBlobSHA computes the SHA git (and GitHub) assigns to a blob, so
+// uploads can be matched against the saved state without the network.
+func gitBlobSHA(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,109 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kickstartdev/kickstart/internal/debug"
+)
+
+// extractTar extracts the entries below dir from a GitHub repository
+// tarball into dest. GitHub wraps everything in a single top-level
+// directory ("owner-repo-sha/"), which is stripped first.
+//
+// Paths and symlink targets are checked to stay inside dest, and symlinks
+// are only created once every file is written, so no entry can be written
+// through a link.
+func extractTar(tr *tar.Reader, dir string, dest string) error {
+	prefix := strings.Trim(dir, "/") + "/"
+
+	type link struct{ path, target string }
+	var links []link
+	found := false
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// drop the top-level directory
+		name := hdr.Name
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[i+1:]
+		} else {
+			continue
+		}
+
+		if !strings.HasPrefix(name+"/", prefix) {
+			continue
+		}
+		found = true
+
+		relPath := strings.TrimPrefix(strings.TrimPrefix(name, strings.TrimSuffix(prefix, "/")), "/")
+		relPath = strings.TrimSuffix(relPath, "/")
+		if relPath == "" {
+			continue
+		}
+		if path.IsAbs(relPath) || !filepath.IsLocal(filepath.FromSlash(relPath)) {
+			return fmt.Errorf("refusing to extract %s: path escapes the project", hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(relPath))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			mode := modeFile
+			if hdr.Mode&0111 != 0 {
+				mode = modeExecutable
+			}
+			if err := writeEntry(target, mode, data); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if !linkInside(relPath, hdr.Linkname) {
+				return fmt.Errorf("refusing to extract %s: symlink to %s escapes the project", hdr.Name, hdr.Linkname)
+			}
+			links = append(links, link{path: target, target: hdr.Linkname})
+		default:
+			// git archives only hold files, dirs and symlinks, plus pax headers
+			debug.Log("extractTar: skipping %s (type %c)", hdr.Name, hdr.Typeflag)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%s/ not found in template", strings.TrimSuffix(prefix, "/"))
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for _, l := range links {
+		if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+			return err
+		}
+		if err := writeEntry(l.path, modeSymlink, []byte(l.target)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
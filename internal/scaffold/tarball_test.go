@@ -0,0 +1,77 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+}
+
+func buildTar(t *testing.T, entries []tarEntry) *tar.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0644}
+		var data []byte
+		if e.typeflag == tar.TypeReg {
+			data = []byte("content")
+			hdr.Size = int64(len(data))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return tar.NewReader(&buf)
+}
+
+func TestExtractTar(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   tarEntry
+		wantErr bool
+	}{
+		{"file", tarEntry{name: "repo-sha/skeleton/a/b.txt", typeflag: tar.TypeReg}, false},
+		{"dot dot name", tarEntry{name: "repo-sha/skeleton/../../evil.txt", typeflag: tar.TypeReg}, true},
+		{"dot dot inside name", tarEntry{name: "repo-sha/skeleton/a/../../evil.txt", typeflag: tar.TypeReg}, true},
+		{"sibling link", tarEntry{name: "repo-sha/skeleton/a/link", typeflag: tar.TypeSymlink, linkname: "b.txt"}, false},
+		{"parent link", tarEntry{name: "repo-sha/skeleton/a/link", typeflag: tar.TypeSymlink, linkname: "../c"}, false},
+		{"absolute link", tarEntry{name: "repo-sha/skeleton/link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}, true},
+		{"escaping link", tarEntry{name: "repo-sha/skeleton/link", typeflag: tar.TypeSymlink, linkname: "../outside"}, true},
+		{"deep escaping link", tarEntry{name: "repo-sha/skeleton/a/link", typeflag: tar.TypeSymlink, linkname: "../../../../etc"}, true},
+		{"climb after a name", tarEntry{name: "repo-sha/skeleton/a/link", typeflag: tar.TypeSymlink, linkname: "b/../.."}, true},
+		{"empty link", tarEntry{name: "repo-sha/skeleton/link", typeflag: tar.TypeSymlink, linkname: ""}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "project")
+			tr := buildTar(t, []tarEntry{
+				{name: "repo-sha/skeleton/", typeflag: tar.TypeDir},
+				tt.entry,
+			})
+
+			err := extractTar(tr, "skeleton", dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractTar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := os.Lstat(filepath.Join(root, "evil.txt")); err == nil {
+				t.Fatal("a file was written outside the project")
+			}
+		})
+	}
+}
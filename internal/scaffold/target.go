@@ -0,0 +1,240 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Step 3 (pull request mode): commit the project into TargetDir of the
+// Target repository, on a new branch off its default branch.
+func (s *Scaffolder) pushBranch() error {
+	base, err := s.getDefaultBranch(s.Target)
+	if err != nil {
+		return err
+	}
+	s.TargetBase = base
+
+	exists, err := s.pathExists(s.Target, s.TargetDir, base)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s already exists in %s", s.TargetDir, s.Target)
+	}
+
+	files, err := s.collectFiles(s.TargetDir)
+	if err != nil {
+		return err
+	}
+
+	parentSHA, err := s.getHeadSHA(s.Target, base)
+	if err != nil {
+		return err
+	}
+	baseTree, err := s.getCommitTree(s.Target, parentSHA)
+	if err != nil {
+		return err
+	}
+
+	treeEntries, err := s.buildTree(s.Target, files)
+	if err != nil {
+		return err
+	}
+	treeSHA, err := s.createTree(s.Target, treeEntries, baseTree)
+	if err != nil {
+		return err
+	}
+
+	message, err := s.commitMessage(fmt.Sprintf("Add %s from template %s", s.TargetDir, s.Repo))
+	if err != nil {
+		return err
+	}
+	commitSHA, err := s.createCommit(s.Target, treeSHA, message, parentSHA)
+	if err != nil {
+		return err
+	}
+	if err := s.createRef(s.Target, s.TargetBranch, commitSHA); err != nil {
+		return err
+	}
+	s.CreatedBranch = s.TargetBranch
+	return nil
+}
+
+// deleteBranch undoes pushBranch, leaving alone a branch it didn't create.
+func (s *Scaffolder) deleteBranch() error {
+	if s.CreatedBranch == "" {
+		return nil
+	}
+	if err := s.deleteRef(s.Target, s.CreatedBranch); err != nil {
+		return err
+	}
+	s.CreatedBranch = ""
+	return nil
+}
+
+func (s *Scaffolder) deleteRef(repo string, branch string) error {
+	req, err := http.NewRequest("DELETE",
+		fmt.Sprintf("https://api.github.com/repos/%s/git/refs/heads/%s", repo, branch),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// a missing ref is reported as 422 rather than 404
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusUnprocessableEntity {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete branch %s: %d %s", branch, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// Step 4 (pull request mode): open a pull request for the new branch. The
+// rendered copy isn't needed any more, the project lives in the branch.
+func (s *Scaffolder) openPullRequest() error {
+	owner, repo, _ := strings.Cut(s.Target, "/")
+	url, err := s.createPullRequest(owner, repo, pullRequest{
+		Title: "Add " + s.TargetDir,
+		Head:  s.TargetBranch,
+		Base:  s.TargetBase,
+		Body:  fmt.Sprintf("Scaffolds `%s` from the %s template.\n", s.TargetDir, s.Source.Name()),
+	})
+	if err != nil {
+		return err
+	}
+	s.PullRequest = url
+
+	os.RemoveAll(s.OutputDir)
+	return nil
+}
+
+func (s *Scaffolder) getDefaultBranch(repo string) (string, error) {
+	req, err := http.NewRequest("GET", "https://api.github.com/repos/"+repo, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("can't access %s: status %d", repo, resp.StatusCode)
+	}
+
+	var result struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.DefaultBranch, nil
+}
+
+func (s *Scaffolder) pathExists(repo string, path string, ref string) (bool, error) {
+	req, err := http.NewRequest("GET",
+		fmt.Sprintf("https://api.github.com/repos/%s/contents/%s?ref=%s", repo, strings.Trim(path, "/"), ref),
+		nil,
+	)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check %s in %s: status %d", path, repo, resp.StatusCode)
+}
+
+func (s *Scaffolder) getCommitTree(repo string, commitSHA string) (string, error) {
+	req, err := http.NewRequest("GET",
+		fmt.Sprintf("https://api.github.com/repos/%s/git/commits/%s", repo, commitSHA),
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get commit %s: %d %s", commitSHA, resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Tree.SHA, nil
+}
+
+func (s *Scaffolder) createRef(repo string, branch string, commitSHA string) error {
+	body, err := json.Marshal(map[string]string{"ref": "refs/heads/" + branch, "sha": commitSHA})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST",
+		fmt.Sprintf("https://api.github.com/repos/%s/git/refs", repo),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return fmt.Errorf("branch %s already exists in %s", branch, repo)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to create branch %s: %d %s", branch, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,468 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// ErrUpToDate is returned when the project already uses the requested
+// template version.
+var ErrUpToDate = errors.New("already up to date")
+
+// githubRemote extracts owner and repo from https and ssh GitHub remotes.
+var githubRemote = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// Updater re-applies a newer version of a project's template. Both the
+// version the project was generated from and the new one are rendered with
+// the answers recorded in its provenance file, and the difference between
+// them is merged into the project on a new branch, the way git merges
+// branches: files the project didn't touch are replaced, edited files are
+// merged with git merge-file and left with conflict markers where both
+// sides changed the same lines.
+type Updater struct {
+	Token string
+	// Dir is the project, a clean git checkout with a provenance file
+	Dir string
+	// Ref is the template version to update to, by default the ref the
+	// project was generated from
+	Ref string
+
+	Provenance *Provenance
+	// Branch is the branch the update is committed on
+	Branch string
+	// Conflicts lists the files that need resolving before merging
+	Conflicts []string
+	// PullRequest is the URL of the opened pull request, if any
+	PullRequest string
+
+	base   string // the project branch the update started from
+	target string // the template commit updating to
+	work   string
+	old    *Scaffolder
+	latest *Scaffolder
+}
+
+// NewUpdater prepares updating the project in dir to the template at ref.
+func NewUpdater(token string, dir string, ref string) (*Updater, error) {
+	p, err := ReadProvenance(dir)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s has no %s, it wasn't generated by kickstart", dir, ProvenanceFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Template.Repo == "" {
+		return nil, fmt.Errorf("%s was generated from the local template %s, only GitHub templates can be updated", dir, p.Template.Path)
+	}
+	if p.Template.Commit == "" {
+		return nil, fmt.Errorf("%s doesn't record the template commit it was generated from", ProvenanceFile)
+	}
+
+	if ref == "" {
+		ref = p.Template.Ref
+	}
+	return &Updater{Token: token, Dir: dir, Ref: ref, Provenance: p}, nil
+}
+
+func (u *Updater) Steps() []Step {
+	return []Step{
+		{Name: "Checking project", Fn: u.check},
+		{Name: "Rendering current template version", Fn: u.renderOld},
+		{Name: "Rendering new template version", Fn: u.renderNew},
+		{Name: "Merging changes", Fn: u.merge},
+		{Name: "Committing update", Fn: u.commit},
+		{Name: "Opening pull request", Fn: u.openPullRequest},
+	}
+}
+
+// Cleanup removes the rendered template versions.
+func (u *Updater) Cleanup() {
+	if u.work != "" {
+		os.RemoveAll(u.work)
+	}
+}
+
+func (u *Updater) check() error {
+	status, err := u.git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		return fmt.Errorf("%s has uncommitted changes, commit or stash them first", u.Dir)
+	}
+
+	u.base, err = u.git("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return fmt.Errorf("%s is not on a branch", u.Dir)
+	}
+
+	owner, repo, _ := strings.Cut(u.Provenance.Template.Repo, "/")
+	ref := u.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	u.target, err = (&GitHubSource{Token: u.Token, Owner: owner, Repo: repo, Ref: ref}).Revision()
+	if err != nil {
+		return err
+	}
+	u.Branch = "kickstart/update-" + u.target[:7]
+	if u.target == u.Provenance.Template.Commit {
+		// the template didn't move, but what it extends or includes may have
+		moved, err := u.movedBase()
+		if err != nil {
+			return err
+		}
+		if moved == "" {
+			return ErrUpToDate
+		}
+		u.Branch += "-" + moved[:7]
+	}
+
+	u.work, err = os.MkdirTemp("", "kickstart-update-")
+	return err
+}
+
+// movedBase returns the new commit of the first template the project's
+// template extends or includes that moved since it was generated, "" if
+// none did.
+func (u *Updater) movedBase() (string, error) {
+	refs := make([]string, 0, len(u.Provenance.Template.Bases))
+	for ref := range u.Provenance.Template.Bases {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	for _, ref := range refs {
+		repo, version, _ := strings.Cut(ref, "@")
+		owner, name, _ := strings.Cut(repo, "/")
+		current, err := (&GitHubSource{Token: u.Token, Owner: owner, Repo: name, Ref: version}).Revision()
+		if err != nil {
+			return "", err
+		}
+		if current != u.Provenance.Template.Bases[ref] {
+			return current, nil
+		}
+	}
+	return "", nil
+}
+
+func (u *Updater) renderOld() (err error) {
+	u.old, err = u.render(u.Provenance.Template.Commit, u.Provenance.Template.Ref, u.Provenance.Template.Bases, "old")
+	return err
+}
+
+func (u *Updater) renderNew() (err error) {
+	// the templates it extends or includes are resolved afresh, so their
+	// changes are part of the update
+	u.latest, err = u.render(u.target, u.Ref, nil, "new")
+	return err
+}
+
+// render renders the template at commit, with the templates it extends or
+// includes at the commits in bases, into the work directory name.
+func (u *Updater) render(commit string, ref string, bases map[string]string, name string) (*Scaffolder, error) {
+	owner, repo, _ := strings.Cut(u.Provenance.Template.Repo, "/")
+	source := &GitHubSource{Token: u.Token, Owner: owner, Repo: repo, Ref: commit}
+
+	bases = maps.Clone(bases)
+	if bases == nil {
+		bases = make(map[string]string)
+	}
+	cfg, _, err := compose(u.Token, source, nil, bases)
+	if err != nil {
+		return nil, err
+	}
+
+	// secrets aren't recorded and variables added since have no answer,
+	// fall back to defaults for both so they render the same on each side
+	variables := make(map[string]string)
+	for _, v := range cfg.Variables {
+		variables[v.Name] = v.Default
+	}
+	for name, value := range u.Provenance.Variables {
+		variables[name] = value
+	}
+	if u.Provenance.Variant != "" {
+		variables[VariantVariable] = u.Provenance.Variant
+	}
+
+	s := New(u.Token, github.Template{Config: *cfg, Owner: owner, Repo: repo, Bases: bases}, variables["project_name"], variables)
+	s.Source = source
+	s.Branch = ref
+	s.Mode = ModeDryRun
+	s.OutputDir = filepath.Join(u.work, name)
+
+	if err := s.downloadSkeleton(); err != nil {
+		return nil, err
+	}
+	if err := s.replaceVariables(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (u *Updater) merge() (err error) {
+	if _, err := u.git("checkout", "--quiet", "-b", u.Branch); err != nil {
+		return err
+	}
+	// leave the project as it was if the merge doesn't finish
+	defer func() {
+		if err != nil {
+			u.abort()
+		}
+	}()
+
+	paths := make(map[string]bool)
+	for _, dir := range []string{u.old.OutputDir, u.latest.OutputDir} {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			relPath, _ := filepath.Rel(dir, path)
+			// both sides record the running kickstart version, so merging
+			// it would conflict after every upgrade
+			if relPath != ProvenanceFile {
+				paths[relPath] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for relPath := range paths {
+		sorted = append(sorted, relPath)
+	}
+	sort.Strings(sorted)
+
+	for _, relPath := range sorted {
+		if err := u.mergePath(relPath); err != nil {
+			return fmt.Errorf("%s: %w", filepath.ToSlash(relPath), err)
+		}
+	}
+
+	// the new render's provenance records the new commit and ref, this
+	// kickstart's version and the recorded answers
+	provenance, err := os.ReadFile(filepath.Join(u.latest.OutputDir, ProvenanceFile))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(u.Dir, ProvenanceFile), provenance, 0644)
+}
+
+type mergeEntry struct {
+	data   []byte
+	mode   string
+	exists bool
+}
+
+func readMergeEntry(path string) (mergeEntry, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return mergeEntry{}, nil
+	}
+	if err != nil {
+		return mergeEntry{}, err
+	}
+	if info.IsDir() {
+		return mergeEntry{}, fmt.Errorf("%s is a directory", path)
+	}
+	data, mode, err := readEntry(path, info)
+	return mergeEntry{data: data, mode: mode, exists: true}, err
+}
+
+func (e mergeEntry) equal(o mergeEntry) bool {
+	return e.exists == o.exists && e.mode == o.mode && bytes.Equal(e.data, o.data)
+}
+
+// mergePath applies the template's change to one file.
+func (u *Updater) mergePath(relPath string) error {
+	basePath := filepath.Join(u.old.OutputDir, relPath)
+	newPath := filepath.Join(u.latest.OutputDir, relPath)
+	curPath := filepath.Join(u.Dir, relPath)
+
+	base, err := readMergeEntry(basePath)
+	if err != nil {
+		return err
+	}
+	theirs, err := readMergeEntry(newPath)
+	if err != nil {
+		return err
+	}
+	cur, err := readMergeEntry(curPath)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case base.equal(theirs), cur.equal(theirs):
+		// the template didn't change it, or the project already has the change
+		return nil
+	case !cur.exists:
+		// a new template file, unless the project deleted it on purpose
+		if base.exists {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(curPath), 0755); err != nil {
+			return err
+		}
+		return writeEntry(curPath, theirs.mode, theirs.data)
+	case !theirs.exists:
+		// removed from the template, keep it if the project changed it
+		if cur.equal(base) {
+			return os.Remove(curPath)
+		}
+		return nil
+	case cur.equal(base):
+		os.Remove(curPath)
+		return writeEntry(curPath, theirs.mode, theirs.data)
+	}
+
+	// both sides changed the file
+	mode := cur.mode
+	if base.mode != theirs.mode && cur.mode == base.mode {
+		mode = theirs.mode
+	}
+	if mode == modeSymlink || theirs.mode == modeSymlink || isBinaryData(cur.data) || isBinaryData(theirs.data) {
+		// nothing to merge line by line, keep the project's version
+		u.Conflicts = append(u.Conflicts, filepath.ToSlash(relPath))
+		return nil
+	}
+
+	if !base.exists {
+		basePath = os.DevNull
+	}
+	merged, conflicts, err := mergeFile(curPath, basePath, newPath)
+	if err != nil {
+		return err
+	}
+	if conflicts {
+		u.Conflicts = append(u.Conflicts, filepath.ToSlash(relPath))
+	}
+	return writeEntry(curPath, mode, merged)
+}
+
+func isBinaryData(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// mergeFile three-way merges the changes from base to theirs into cur with
+// git merge-file, leaving conflict markers where they overlap.
+func mergeFile(cur string, base string, theirs string) ([]byte, bool, error) {
+	cmd := execCommand("git", "merge-file", "-p",
+		"-L", "project", "-L", "previous template", "-L", "new template",
+		cur, base, theirs,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+
+	// the exit code is the number of conflicts, negative on errors
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return output, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("git merge-file failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return output, false, nil
+}
+
+// abort puts the project back on the branch it was on and drops the update
+// branch. The tree was clean when the update started, so every change and
+// untracked file is the update's.
+func (u *Updater) abort() {
+	u.git("reset", "--quiet", "--hard")
+	u.git("clean", "--quiet", "-d", "--force")
+	u.git("checkout", "--quiet", u.base)
+	u.git("branch", "--quiet", "-D", u.Branch)
+}
+
+func (u *Updater) commit() (err error) {
+	defer func() {
+		if err != nil {
+			u.abort()
+		}
+	}()
+	commands := [][]string{
+		{"add", "--all"},
+		{"commit", "--quiet", "--allow-empty", "-m", u.commitMessage()},
+	}
+	for _, args := range commands {
+		if _, err := u.git(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *Updater) commitMessage() string {
+	msg := fmt.Sprintf("Update from template %s@%s", u.Provenance.Template.Repo, u.target[:7])
+	if len(u.Conflicts) > 0 {
+		msg += "\n\nConflicts to resolve:\n"
+		for _, path := range u.Conflicts {
+			msg += "\t" + path + "\n"
+		}
+	}
+	return msg
+}
+
+// openPullRequest pushes the branch and opens a pull request when the
+// project's origin is on GitHub. Otherwise the branch is left for the user
+// to push.
+func (u *Updater) openPullRequest() error {
+	remote, err := u.git("remote", "get-url", "origin")
+	if err != nil {
+		return nil
+	}
+	m := githubRemote.FindStringSubmatch(remote)
+	if m == nil {
+		return nil
+	}
+
+	if _, err := u.git("push", "--quiet", "--set-upstream", "origin", u.Branch); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Updates the project from %s@%s to %s@%s.\n",
+		u.Provenance.Template.Repo, u.Provenance.Template.Commit[:7],
+		u.Provenance.Template.Repo, u.target[:7],
+	)
+	if len(u.Conflicts) > 0 {
+		body += "\nThese files have conflicts to resolve before merging:\n\n"
+		for _, path := range u.Conflicts {
+			body += "- `" + path + "`\n"
+		}
+	}
+
+	u.PullRequest, err = u.latest.createPullRequest(m[1], m[2], pullRequest{
+		Title: fmt.Sprintf("Update from template %s", u.Provenance.Template.Repo),
+		Head:  u.Branch,
+		Base:  u.base,
+		Body:  body,
+	})
+	return err
+}
+
+// git runs a git command in the project and returns its trimmed output.
+func (u *Updater) git(args ...string) (string, error) {
+	cmd := execCommand("git", args...)
+	cmd.Dir = u.Dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,135 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMergePath(t *testing.T) {
+	const original = "one\ntwo\nthree\nfour\nfive\n"
+
+	tests := []struct {
+		name string
+		// contents of the file in the previous render, the new render and
+		// the project; nil when it doesn't exist there
+		base, theirs, cur *string
+		// want is the project's file afterwards, nil when it's gone
+		want         *string
+		wantConflict bool
+	}{
+		{
+			name:   "clean merge",
+			base:   ptr(original),
+			theirs: ptr("one\ntwo\nthree\nfour\nFIVE\n"),
+			cur:    ptr("ONE\ntwo\nthree\nfour\nfive\n"),
+			want:   ptr("ONE\ntwo\nthree\nfour\nFIVE\n"),
+		},
+		{
+			name:         "conflict",
+			base:         ptr(original),
+			theirs:       ptr("one\ntwo\ntemplate\nfour\nfive\n"),
+			cur:          ptr("one\ntwo\nproject\nfour\nfive\n"),
+			wantConflict: true,
+		},
+		{
+			name:   "unchanged file replaced",
+			base:   ptr(original),
+			theirs: ptr("new\n"),
+			cur:    ptr(original),
+			want:   ptr("new\n"),
+		},
+		{
+			name:   "deleted upstream",
+			base:   ptr(original),
+			theirs: nil,
+			cur:    ptr(original),
+			want:   nil,
+		},
+		{
+			name:   "deleted upstream, changed locally",
+			base:   ptr(original),
+			theirs: nil,
+			cur:    ptr("changed\n"),
+			want:   ptr("changed\n"),
+		},
+		{
+			name:   "added upstream",
+			base:   nil,
+			theirs: ptr("added\n"),
+			cur:    nil,
+			want:   ptr("added\n"),
+		},
+		{
+			name:   "added locally",
+			base:   nil,
+			theirs: nil,
+			cur:    ptr("mine\n"),
+			want:   ptr("mine\n"),
+		},
+		{
+			name:   "deleted locally",
+			base:   ptr(original),
+			theirs: ptr("one\ntwo\nthree\nfour\nFIVE\n"),
+			cur:    nil,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			u := &Updater{
+				Dir:    filepath.Join(root, "project"),
+				old:    &Scaffolder{OutputDir: filepath.Join(root, "old")},
+				latest: &Scaffolder{OutputDir: filepath.Join(root, "new")},
+			}
+			writeTestFile(t, filepath.Join(u.old.OutputDir, "file.txt"), tt.base)
+			writeTestFile(t, filepath.Join(u.latest.OutputDir, "file.txt"), tt.theirs)
+			writeTestFile(t, filepath.Join(u.Dir, "file.txt"), tt.cur)
+
+			if err := u.mergePath("file.txt"); err != nil {
+				t.Fatalf("mergePath() error = %v", err)
+			}
+
+			if conflict := slices.Contains(u.Conflicts, "file.txt"); conflict != tt.wantConflict {
+				t.Errorf("conflict = %v, want %v", conflict, tt.wantConflict)
+			}
+
+			data, err := os.ReadFile(filepath.Join(u.Dir, "file.txt"))
+			switch {
+			case tt.wantConflict:
+				if err != nil || !strings.Contains(string(data), "<<<<<<< project") {
+					t.Errorf("want conflict markers, got %q (%v)", data, err)
+				}
+			case tt.want == nil:
+				if !os.IsNotExist(err) {
+					t.Errorf("want no file, got %q (%v)", data, err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case string(data) != *tt.want:
+				t.Errorf("got %q, want %q", data, *tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func writeTestFile(t *testing.T, path string, content *string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if content == nil {
+		return
+	}
+	if err := os.WriteFile(path, []byte(*content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
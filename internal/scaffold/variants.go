@@ -0,0 +1,46 @@
+package scaffold
+
+import (
+	"fmt"
+
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// VariantVariable is the form question choosing the variant. The answer is
+// also available to templates, e.g. {{if eq .variant "grpc"}}.
+const VariantVariable = "variant"
+
+// FormVariables are the questions asked for a template: which variant,
+// if it has any, then its variables.
+func FormVariables(cfg github.TemplateConfig) []github.Variable {
+	if len(cfg.Variants) == 0 {
+		return cfg.Variables
+	}
+
+	question := github.Variable{
+		Name:        VariantVariable,
+		Description: "flavour of the template",
+		Type:        github.TypeChoice,
+		Required:    true,
+	}
+	for _, v := range cfg.Variants {
+		question.Choices = append(question.Choices, v.Name)
+	}
+	return append([]github.Variable{question}, cfg.Variables...)
+}
+
+// variant returns the chosen variant, nil for templates without variants.
+func (s *Scaffolder) variant() (*github.Variant, error) {
+	if len(s.Config.Variants) == 0 {
+		return nil, nil
+	}
+	if s.Variant == "" {
+		return &s.Config.Variants[0], nil
+	}
+	for i := range s.Config.Variants {
+		if s.Config.Variants[i].Name == s.Variant {
+			return &s.Config.Variants[i], nil
+		}
+	}
+	return nil, fmt.Errorf("template has no variant %q", s.Variant)
+}
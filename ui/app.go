@@ -39,18 +39,40 @@ type Model struct {
 	SelectedTemplate github.Template
 	Table	table.Model
 
+	//versions
+	Versions        []github.Ref
+	VersionsLoading bool
+	VersionsError   string
+	VersionCursor   int
+	// VersionInput takes a commit SHA, or any ref, that isn't listed
+	VersionInput    textinput.Model
+	VersionEntering bool
+
 	//form
 	FormInputs []textinput.Model
 	FormCursor	int
 	FormValues map[string]string
+	RepoValues map[string]string
 	FormLoading bool
 	FormError	string
+	// Resuming is the saved scaffold whose secrets the form asks for
+	Resuming	*scaffold.State
 
 	//scaffolding
 	Scaffolder      *scaffold.Scaffolder
 	ScaffoldSteps   []scaffoldStep
 	ScaffoldCurrent int
 	ScaffoldError   string
+	RunHooks        bool
+	ScaffoldRollingBack bool
+	ScaffoldRolledBack  bool
+	ScaffoldProgress    scaffoldProgressMsg
+	ScaffoldProgressCh  chan scaffoldProgressMsg
+	DryRun              bool
+	Local               bool
+	Remote              string
+	Into                string
+	IntoPath            string
 
 
 
@@ -61,18 +83,65 @@ const (
 	screenAuth        = "auth"
 	screenAuthSuccess = "auth_success"
 	screenTemplates   = "templates"
+	screenVersions    = "versions"
 	screenForm        = "form"
+	screenTrust       = "trust"
 	screenScaffolding = "scaffolding"
 	screenSuccess     = "success"
 )
 
-func NewApp() *Model {
+// Options are set from the command line.
+type Options struct {
+	// Resume continues an interrupted scaffold instead of starting a new one
+	Resume *scaffold.State
+	// Template skips the template list, e.g. for a local template directory
+	Template *github.Template
+	// DryRun renders projects locally without creating repositories
+	DryRun bool
+	// Local creates a local git repository instead of a GitHub one,
+	// with Remote added as origin if set
+	Local  bool
+	Remote string
+	// Into adds the project to this existing owner/repo through a pull
+	// request, in IntoPath if set
+	Into     string
+	IntoPath string
+}
+
+func NewApp(opts Options) *Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
 
 	cfg, err := auth.LoadConfig()
 
+	if err == nil && cfg.Token != "" && opts.Resume != nil {
+		debug.Log("NewApp: resuming scaffold of %s", opts.Resume.ProjectName)
+		m := &Model{
+			Token:    cfg.Token,
+			Username: cfg.Username,
+			Spinner:  s,
+		}
+		m.resumeScaffolding(opts.Resume)
+		return m
+	}
+
+	if err == nil && cfg.Token != "" && opts.Template != nil {
+		debug.Log("NewApp: using template %s", opts.Template.Path)
+		m := &Model{
+			Token:    cfg.Token,
+			Username: cfg.Username,
+			Spinner:  s,
+			DryRun:   opts.DryRun,
+			Local:    opts.Local,
+			Remote:   opts.Remote,
+			Into:     opts.Into,
+			IntoPath: opts.IntoPath,
+		}
+		m.useTemplate(*opts.Template)
+		return m
+	}
+
 	if err == nil && cfg.Token != "" {
 		debug.Log("NewApp: found token for user %s, going to templates", cfg.Username)
 		return &Model{
@@ -81,13 +150,28 @@ func NewApp() *Model {
 			Username:         cfg.Username,
 			Spinner:          s,
 			TemplatesLoading: true,
+			DryRun:           opts.DryRun,
+			Local:            opts.Local,
+			Remote:           opts.Remote,
+			Into:             opts.Into,
+			IntoPath:         opts.IntoPath,
 		}
 	}
 
-	return &Model{
-		Screen:  screenWelcome,
-		Spinner: s,
+	m := &Model{
+		Screen:   screenWelcome,
+		Spinner:  s,
+		DryRun:   opts.DryRun,
+		Local:    opts.Local,
+		Remote:   opts.Remote,
+		Into:     opts.Into,
+		IntoPath: opts.IntoPath,
 	}
+	if opts.Template != nil {
+		// picked up again once the user has logged in
+		m.SelectedTemplate = *opts.Template
+	}
+	return m
 }
 
 func (m *Model) Init() tea.Cmd {
@@ -95,6 +179,9 @@ func (m *Model) Init() tea.Cmd {
 	if m.Screen == screenTemplates {
 		return tea.Batch(m.Spinner.Tick, m.fetchTemplateCmd)
 	}
+	if m.Screen == screenScaffolding {
+		return tea.Batch(m.Spinner.Tick, m.runScaffoldStepCmd(m.ScaffoldCurrent), waitForProgressCmd(m.ScaffoldProgressCh))
+	}
 	return m.Spinner.Tick
 }
 
@@ -111,7 +198,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			return m, tea.Quit
 		case	"q":
-			if m.Screen != screenForm {
+			if m.Screen != screenForm && !m.VersionEntering {
 				return m, tea.Quit
 			}
 		case "enter":
@@ -138,8 +225,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.UpdateAuth(msg)
 	case screenTemplates:
 		return m.UpdateTemplates(msg)
+	case screenVersions:
+		return m.UpdateVersions(msg)
 	case screenForm:
 		return m.UpdateForm(msg)
+	case screenTrust:
+		return m.UpdateTrust(msg)
 	case screenScaffolding:
 		return m.UpdateScaffolding(msg)
 	}
@@ -159,8 +250,12 @@ func (m *Model) View() string {
 		return m.ViewAuthSuccess()
 	case screenTemplates:
 		return m.ViewTemplates()
+	case screenVersions:
+		return m.ViewVersions()
 	case screenForm:
 		return m.ViewForm()
+	case screenTrust:
+		return m.ViewTrust()
 	case screenScaffolding:
 		return m.ViewScaffolding()
 	case screenSuccess:
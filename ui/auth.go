@@ -106,6 +106,10 @@ func (m *Model) UpdateAuth(msg tea.Msg) (tea.Model, tea.Cmd) {
 			})
 
 		case authSuccessTimerMsg:
+			if m.SelectedTemplate.Path != "" {
+				m.useTemplate(m.SelectedTemplate)
+				return m, nil
+			}
 			m.Screen = screenTemplates
 			m.TemplatesLoading = true
 			return m, m.fetchTemplateCmd
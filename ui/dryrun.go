@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+const dryRunMaxRows = 15
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+func (m *Model) ViewDryRun() string {
+	report := m.Scaffolder.Report
+
+	s := greenStyle.Render("Dry run complete, nothing was created on GitHub.") + "\n\n"
+	s += "  " + dimStyle.Render("Rendered to:") + " " + accentStyle.Render(m.Scaffolder.OutputDir) + "\n\n"
+
+	var total int64
+	for _, f := range report.Files {
+		total += f.Size
+	}
+	s += fmt.Sprintf("Files (%d, %s):\n", len(report.Files), formatSize(total))
+	for i, f := range report.Files {
+		if i == dryRunMaxRows {
+			s += dimStyle.Render(fmt.Sprintf("    ... and %d more", len(report.Files)-dryRunMaxRows)) + "\n"
+			break
+		}
+		depth := strings.Count(f.Path, "/")
+		name := f.Path[strings.LastIndex(f.Path, "/")+1:]
+		line := strings.Repeat("  ", depth) + name
+		switch f.Mode {
+		case "100755":
+			line += "*"
+		case "120000":
+			line += "@"
+		}
+		s += fmt.Sprintf("    %-50s %s\n", line, dimStyle.Render(formatSize(f.Size)))
+	}
+
+	s += "\n"
+	if len(report.Placeholders) == 0 {
+		s += greenStyle.Render("No template syntax in files copied verbatim.") + "\n"
+	} else {
+		s += accentStyle.Render(fmt.Sprintf("Template syntax in files copied verbatim (%d):", len(report.Placeholders))) + "\n"
+		for i, p := range report.Placeholders {
+			if i == dryRunMaxRows {
+				s += dimStyle.Render(fmt.Sprintf("    ... and %d more", len(report.Placeholders)-dryRunMaxRows)) + "\n"
+				break
+			}
+			s += fmt.Sprintf("    %s %s\n", dimStyle.Render(fmt.Sprintf("%s:%d", p.Path, p.Line)), p.Text)
+		}
+	}
+
+	repo := report.Repo
+	s += "\nWould create repository:\n"
+	s += "    " + dimStyle.Render("Name:") + "        " + accentStyle.Render(repo.Owner+"/"+repo.Name) + "\n"
+	s += "    " + dimStyle.Render("Visibility:") + "  " + repo.Visibility + "\n"
+	if repo.Description != "" {
+		s += "    " + dimStyle.Render("Description:") + " " + repo.Description + "\n"
+	}
+	if repo.Homepage != "" {
+		s += "    " + dimStyle.Render("Homepage:") + "    " + repo.Homepage + "\n"
+	}
+	if len(repo.Topics) > 0 {
+		s += "    " + dimStyle.Render("Topics:") + "      " + strings.Join(repo.Topics, ", ") + "\n"
+	}
+	s += "    " + dimStyle.Render("Branch:") + "      " + repo.Branch + "\n"
+	s += "    " + dimStyle.Render("Template:") + "    " + repo.Template + "\n"
+
+	return m.Layout(s, "q quit")
+}
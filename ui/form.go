@@ -1,14 +1,18 @@
 package ui
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kickstartdev/kickstart/internal/github"
+	"github.com/kickstartdev/kickstart/internal/scaffold"
 )
 
 type templateConfigLoadedMsg struct {
-	Config github.TemplateConfig
+	Template github.Template
 }
 
 type templateConfigErrMsg struct {
@@ -16,31 +20,73 @@ type templateConfigErrMsg struct {
 }
 
 func (m *Model) fetchTemplateLoadedConfigCmd() tea.Msg {
-	cfg, err := github.GetTemplateConfig(
-		m.Token,
-		m.SelectedTemplate.Owner,
-		m.SelectedTemplate.Repo,
-	)
+	tmpl, err := scaffold.LoadConfig(m.Token, m.SelectedTemplate)
 
 	if err != nil {
 		return templateConfigErrMsg{Err: err}
 	}
 
-	return templateConfigLoadedMsg{Config: *cfg}
+	return templateConfigLoadedMsg{Template: tmpl}
+}
+
+// useTemplate goes straight to the form for a template whose config is
+// already loaded.
+func (m *Model) useTemplate(tmpl github.Template) {
+	m.SelectedTemplate = tmpl
+	m.Screen = screenForm
+	m.FormLoading = false
+	m.buildFormInputs()
+}
+
+// formVariables are the form's questions, starting with the variant for
+// templates that have variants and ending with the settings of the
+// repository to create, if one is created.
+func (m *Model) formVariables() []github.Variable {
+	if m.Resuming != nil {
+		return m.Resuming.MissingSecrets()
+	}
+	variables := scaffold.FormVariables(m.SelectedTemplate.Config)
+	if m.Local || m.Into != "" {
+		return variables
+	}
+	return append(variables, scaffold.RepoQuestions(m.SelectedTemplate.Config.Repository)...)
+}
+
+// repoQuestionsStart is the index of the first repository question.
+func (m *Model) repoQuestionsStart() int {
+	return len(scaffold.FormVariables(m.SelectedTemplate.Config))
+}
+
+func newTextInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = ""
+	ti.CharLimit = 100
+	ti.Width = 40
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#f0883e"))
+	ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#e6edf3"))
+	ti.PlaceholderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#30363d"))
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#f0883e"))
+	return ti
 }
 
 func (m *Model) buildFormInputs() {
-	m.FormInputs = make([]textinput.Model, len(m.SelectedTemplate.Config.Variables))
-
-	for i := range m.SelectedTemplate.Config.Variables {
-		ti := textinput.New()
-		ti.Placeholder = ""
-		ti.CharLimit = 100
-		ti.Width = 40
-		ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#f0883e"))
-		ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#e6edf3"))
-		ti.PlaceholderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#30363d"))
-		ti.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#f0883e"))
+	variables := m.formVariables()
+	m.FormInputs = make([]textinput.Model, len(variables))
+
+	for i, v := range variables {
+		ti := newTextInput()
+
+		switch v.Type {
+		case github.TypeSecret:
+			ti.EchoMode = textinput.EchoPassword
+			ti.EchoCharacter = '•'
+		case github.TypeChoice:
+			if v.Default != "" {
+				ti.SetValue(v.Default)
+			} else if len(v.Choices) > 0 {
+				ti.SetValue(v.Choices[0])
+			}
+		}
 
 		if i == 0 {
 			ti.Focus()
@@ -56,7 +102,7 @@ func (m *Model) buildFormInputs() {
 func (m *Model) UpdateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case templateConfigLoadedMsg:
-		m.SelectedTemplate.Config = msg.Config
+		m.SelectedTemplate = msg.Template
 		m.FormLoading = false
 		m.buildFormInputs()
 		return m, nil
@@ -67,6 +113,11 @@ func (m *Model) UpdateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m,nil
 
 	case tea.KeyMsg:
+		// until the config is loaded the inputs may be the previous template's
+		if m.FormLoading {
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "tab", "down":
 			if m.FormCursor < len(m.FormInputs)-1 {
@@ -87,10 +138,16 @@ func (m *Model) UpdateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		
 		case "enter":
-			if m.FormCursor == len(m.FormInputs)-1 {
+			if m.FormError != "" {
+				return m, nil
+			}
+			if m.FormCursor >= len(m.FormInputs)-1 {
 				m.collectFormValues()
-				m.Screen = screenScaffolding
-				return m, m.startScaffoldingCmd
+				if st := m.Resuming; st != nil {
+					m.Resuming = nil
+					return m, m.resumeWithSecrets(st)
+				}
+				return m.startScaffolding()
 			}
 
 			m.FormInputs[m.FormCursor].Blur()
@@ -99,9 +156,28 @@ func (m *Model) UpdateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m,nil
 
 		case "esc" :
-			m.Screen = screenTemplates
+			// local templates are given on the command line, there is no list
+			if m.SelectedTemplate.Path != "" || m.Resuming != nil {
+				return m, nil
+			}
+			m.Screen = screenVersions
+			return m, nil
+		}
+
+		variables := m.formVariables()
+		if m.FormCursor >= len(m.FormInputs) || m.FormCursor >= len(variables) {
 			return m, nil
 		}
+
+		// choices are picked with the arrow keys, not typed
+		if v := variables[m.FormCursor]; v.Type == github.TypeChoice {
+			m.cycleChoice(v, msg.String())
+			return m, nil
+		}
+	}
+
+	if m.FormCursor >= len(m.FormInputs) {
+		return m, nil
 	}
 
 	var cmd tea.Cmd
@@ -113,12 +189,22 @@ func (m *Model) UpdateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) collectFormValues() {
 	m.FormValues = make(map[string]string)
-	for i, v := range m.SelectedTemplate.Config.Variables {
+	m.RepoValues = nil
+	variables := m.formVariables()
+	if len(variables) > m.repoQuestionsStart() {
+		// only set when the repository questions were asked
+		m.RepoValues = make(map[string]string)
+	}
+	for i, v := range variables {
 		value := m.FormInputs[i].Value()
 		if value == "" {
 			value = v.Default
 		}
-		m.FormValues[v.Name] = value
+		if i >= m.repoQuestionsStart() {
+			m.RepoValues[v.Name] = value
+		} else {
+			m.FormValues[v.Name] = value
+		}
 	}
 }
 
@@ -137,14 +223,22 @@ func (m *Model) ViewForm() string {
 
 	template := m.SelectedTemplate.Config
 	s := accentStyle.Render(template.Name) + "  " + dimStyle.Render(template.Description) + "\n"
-	s += dimStyle.Render(m.SelectedTemplate.Owner+"/"+m.SelectedTemplate.Repo) + "\n\n"
+	source := m.templateSource()
+	if m.SelectedTemplate.Ref != "" {
+		source += "@" + m.SelectedTemplate.Ref
+	}
+	s += dimStyle.Render(source) + "\n\n"
 	s += "Configure your project:\n\n"
 
 	labelColor := lipgloss.NewStyle().Foreground(lipgloss.Color("#e6edf3"))
 	requiredStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#f85149"))
 	rowStyle := lipgloss.NewStyle().Width(22)
 
-	for i, v := range template.Variables {
+	for i, v := range m.formVariables() {
+		if i == m.repoQuestionsStart() {
+			s += "\nRepository:\n\n"
+		}
+
 		cursor := "  "
 		if m.FormCursor == i {
 			cursor = accentStyle.Render(") ")
@@ -160,7 +254,9 @@ func (m *Model) ViewForm() string {
 
 		if m.FormCursor == i {
 			hint := v.Description
-			if v.Default != "" {
+			if v.Type == github.TypeChoice {
+				hint = m.choiceHint(v)
+			} else if v.Default != "" {
 				if hint != "" {
 					hint += " "
 				}
@@ -173,4 +269,40 @@ func (m *Model) ViewForm() string {
 	}
 
 	return m.Layout(s, "tab next   shift+tab back   enter submit   esc cancel")
-}
\ No newline at end of file
+}
+
+// cycleChoice moves the focused choice input to the previous or next
+// choice on left or right.
+func (m *Model) cycleChoice(v github.Variable, key string) {
+	if len(v.Choices) == 0 {
+		return
+	}
+	i := slices.Index(v.Choices, m.FormInputs[m.FormCursor].Value())
+	switch key {
+	case "left", "h":
+		i = (i - 1 + len(v.Choices)) % len(v.Choices)
+	case "right", "l", " ":
+		i = (i + 1) % len(v.Choices)
+	default:
+		return
+	}
+	m.FormInputs[m.FormCursor].SetValue(v.Choices[i])
+}
+
+func (m *Model) choiceHint(v github.Variable) string {
+	hint := "←/→ " + strings.Join(v.Choices, ", ")
+	if v.Name != scaffold.VariantVariable {
+		if v.Description != "" {
+			hint = v.Description + " " + hint
+		}
+		return hint
+	}
+
+	// describe the variant picked rather than the question
+	for _, variant := range m.SelectedTemplate.Config.Variants {
+		if variant.Name == m.FormInputs[0].Value() && variant.Description != "" {
+			return variant.Description + "  " + hint
+		}
+	}
+	return hint
+}
@@ -2,35 +2,100 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/kickstartdev/kickstart/internal/github"
 	"github.com/kickstartdev/kickstart/internal/scaffold"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type scaffoldStepDoneMsg struct {
 	StepIndex int
+	Output    string
 }
 
 type scaffoldErrMsg struct {
-	Err error
+	Err    error
+	Output string
 }
 
 type scaffoldCompleteMsg struct{}
 
-func (m *Model) startScaffoldingCmd() tea.Msg {
-	branch := m.SelectedTemplate.Config.Branch
-	if branch == "" {
-		branch = "main"
+type scaffoldRolledBackMsg struct {
+	Err error
+}
+
+type scaffoldProgressMsg struct {
+	Done  int
+	Total int
+	Path  string
+}
+
+// reportProgress forwards the scaffolder's progress callbacks to the UI.
+// Updates are dropped rather than blocking the upload if the UI lags.
+func (m *Model) reportProgress() {
+	ch := m.ScaffoldProgressCh
+	m.Scaffolder.Progress = func(done int, total int, path string) {
+		select {
+		case ch <- scaffoldProgressMsg{Done: done, Total: total, Path: path}:
+		default:
+		}
+	}
+}
+
+func waitForProgressCmd(ch chan scaffoldProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
 	}
+}
 
+func (m *Model) rollbackCmd() tea.Msg {
+	return scaffoldRolledBackMsg{Err: m.Scaffolder.Rollback(m.ScaffoldCurrent)}
+}
+
+func (m *Model) startScaffoldingCmd() tea.Msg {
+	if m.RepoValues != nil {
+		scaffold.ApplyRepoAnswers(&m.SelectedTemplate.Config.Repository, m.RepoValues)
+	}
 	m.Scaffolder = scaffold.New(
 		m.Token,
-		m.SelectedTemplate.Owner,
-		m.SelectedTemplate.Repo,
-		branch,
+		m.SelectedTemplate,
 		m.FormValues["project_name"],
 		m.FormValues,
 	)
+	m.Scaffolder.RunHooks = m.RunHooks
+	m.reportProgress()
+
+	if m.Local {
+		m.Scaffolder.Mode = scaffold.ModeLocal
+		m.Scaffolder.Remote = m.Remote
+	}
+
+	if m.Into != "" {
+		dir, err := os.MkdirTemp("", "kickstart-"+m.Scaffolder.ProjectName+"-")
+		if err != nil {
+			return scaffoldErrMsg{Err: err}
+		}
+		m.Scaffolder.Mode = scaffold.ModePullRequest
+		m.Scaffolder.OutputDir = filepath.Join(dir, m.Scaffolder.ProjectName)
+		m.Scaffolder.Target = m.Into
+		m.Scaffolder.TargetDir = m.IntoPath
+		if m.Scaffolder.TargetDir == "" {
+			m.Scaffolder.TargetDir = m.Scaffolder.ProjectName
+		}
+		m.Scaffolder.TargetBranch = "kickstart/" + m.Scaffolder.ProjectName
+	}
+
+	if m.DryRun {
+		dir, err := os.MkdirTemp("", "kickstart-"+m.Scaffolder.ProjectName+"-")
+		if err != nil {
+			return scaffoldErrMsg{Err: err}
+		}
+		m.Scaffolder.Mode = scaffold.ModeDryRun
+		m.Scaffolder.OutputDir = filepath.Join(dir, m.Scaffolder.ProjectName)
+	}
 
 	steps := m.Scaffolder.Steps()
 	m.ScaffoldSteps = make([]scaffoldStep, len(steps))
@@ -41,11 +106,65 @@ func (m *Model) startScaffoldingCmd() tea.Msg {
 	m.ScaffoldCurrent = 0
 
 	// run first step
-	err := steps[0].Fn()
-	if err != nil {
-		return scaffoldErrMsg{Err: err}
+	return m.runScaffoldStepCmd(0)()
+}
+
+// scaffoldingCmd runs the current step and listens for its progress.
+func (m *Model) scaffoldingCmd(first tea.Cmd) tea.Cmd {
+	m.ScaffoldProgressCh = make(chan scaffoldProgressMsg, 32)
+	return tea.Batch(first, waitForProgressCmd(m.ScaffoldProgressCh))
+}
+
+// resumeWithSecrets resumes st once the form has the secrets it needs.
+func (m *Model) resumeWithSecrets(st *scaffold.State) tea.Cmd {
+	if st.Variables == nil {
+		st.Variables = make(map[string]string)
+	}
+	for name, value := range m.FormValues {
+		st.Variables[name] = value
 	}
-	return scaffoldStepDoneMsg{StepIndex: 0}
+	m.resumeScaffolding(st)
+	if m.Screen != screenScaffolding {
+		return nil
+	}
+	return tea.Batch(m.runScaffoldStepCmd(m.ScaffoldCurrent), waitForProgressCmd(m.ScaffoldProgressCh))
+}
+
+// resumeScaffolding picks up a scaffold saved by an earlier run at the
+// first step that didn't finish.
+func (m *Model) resumeScaffolding(st *scaffold.State) {
+	if len(st.MissingSecrets()) > 0 {
+		// secrets aren't saved, ask for them before going on
+		m.Resuming = st
+		m.SelectedTemplate = github.Template{Config: st.Config, Owner: st.Owner, Repo: st.Repo, Path: st.TemplateDir}
+		m.Screen = screenForm
+		m.buildFormInputs()
+		return
+	}
+
+	m.Scaffolder = scaffold.Resume(m.Token, st)
+	m.ScaffoldProgressCh = make(chan scaffoldProgressMsg, 32)
+	m.reportProgress()
+	m.SelectedTemplate = github.Template{Config: st.Config, Owner: st.Owner, Repo: st.Repo}
+	m.FormValues = st.Variables
+	m.RunHooks = st.RunHooks
+
+	steps := m.Scaffolder.Steps()
+	m.ScaffoldSteps = make([]scaffoldStep, len(steps))
+	for i, s := range steps {
+		m.ScaffoldSteps[i] = scaffoldStep{Name: s.Name, Status: "pending"}
+		if i < st.Completed {
+			m.ScaffoldSteps[i].Status = "done"
+		}
+	}
+
+	m.Screen = screenScaffolding
+	m.ScaffoldCurrent = st.Completed
+	if m.ScaffoldCurrent >= len(steps) {
+		m.Screen = screenSuccess
+		return
+	}
+	m.ScaffoldSteps[m.ScaffoldCurrent].Status = "running"
 }
 
 func (m *Model) runScaffoldStepCmd(stepIndex int) tea.Cmd {
@@ -57,22 +176,35 @@ func (m *Model) runScaffoldStepCmd(stepIndex int) tea.Cmd {
 		}
 
 		err := steps[stepIndex].Fn()
+
+		var output string
+		if steps[stepIndex].Output != nil {
+			output = steps[stepIndex].Output()
+		}
+
 		if err != nil {
-			return scaffoldErrMsg{Err: err}
+			return scaffoldErrMsg{Err: err, Output: output}
 		}
-		return scaffoldStepDoneMsg{StepIndex: stepIndex}
+		return scaffoldStepDoneMsg{StepIndex: stepIndex, Output: output}
 	}
 }
 
 type scaffoldStep struct {
 	Name   string
 	Status string
+	Output string
 }
 
 func (m *Model) UpdateScaffolding(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case scaffoldProgressMsg:
+		m.ScaffoldProgress = msg
+		return m, waitForProgressCmd(m.ScaffoldProgressCh)
+
 	case scaffoldStepDoneMsg:
+		m.ScaffoldProgress = scaffoldProgressMsg{}
 		m.ScaffoldSteps[msg.StepIndex].Status = "done"
+		m.ScaffoldSteps[msg.StepIndex].Output = msg.Output
 		next := msg.StepIndex + 1
 		if next < len(m.ScaffoldSteps) {
 			m.ScaffoldCurrent = next
@@ -84,19 +216,79 @@ func (m *Model) UpdateScaffolding(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case scaffoldErrMsg:
 		m.ScaffoldSteps[m.ScaffoldCurrent].Status = "error"
+		m.ScaffoldSteps[m.ScaffoldCurrent].Output = msg.Output
 		m.ScaffoldError = msg.Err.Error()
 		return m, nil
+
+	case scaffoldRolledBackMsg:
+		m.ScaffoldRollingBack = false
+		m.ScaffoldRolledBack = true
+		if msg.Err != nil {
+			m.ScaffoldError = "rollback incomplete: " + msg.Err.Error()
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.ScaffoldError == "" || m.ScaffoldRollingBack {
+			return m, nil
+		}
+		switch msg.String() {
+		case "r":
+			if m.ScaffoldRolledBack {
+				return m, nil
+			}
+			// keep what was done so far and run the failed step again
+			m.ScaffoldError = ""
+			m.ScaffoldSteps[m.ScaffoldCurrent].Status = "running"
+			m.ScaffoldSteps[m.ScaffoldCurrent].Output = ""
+			return m, m.runScaffoldStepCmd(m.ScaffoldCurrent)
+
+		case "u":
+			if m.ScaffoldRolledBack {
+				return m, nil
+			}
+			m.ScaffoldRollingBack = true
+			return m, m.rollbackCmd
+
+		case "esc":
+			if m.ScaffoldRolledBack {
+				m.resetScaffolding()
+				m.Screen = screenForm
+			}
+			return m, nil
+		}
 	}
 
 	return m, nil
 }
 
 func (m *Model) ViewSuccess() string {
+	if m.Scaffolder != nil && m.Scaffolder.Report != nil {
+		return m.ViewDryRun()
+	}
+
 	projectName := m.FormValues["project_name"]
 
+	if m.Scaffolder != nil && m.Scaffolder.Mode == scaffold.ModePullRequest {
+		content := greenStyle.Render("Pull request opened!") + "\n\n"
+		content += "  " + dimStyle.Render("Project:") + "   " + accentStyle.Render(projectName) + "\n"
+		content += "  " + dimStyle.Render("Into:") + "      " + accentStyle.Render(m.Scaffolder.Target+"/"+m.Scaffolder.TargetDir) + "\n"
+		content += "  " + dimStyle.Render("Review:") + "    " + accentStyle.Render(m.Scaffolder.PullRequest) + "\n"
+		return m.Layout(content, "q quit")
+	}
+
 	content := greenStyle.Render("Project scaffolded successfully!") + "\n\n"
 	content += "  " + dimStyle.Render("Project:") + "   " + accentStyle.Render(projectName) + "\n"
-	content += "  " + dimStyle.Render("Location:") + "  " + accentStyle.Render("./"+projectName) + "\n\n"
+	content += "  " + dimStyle.Render("Location:") + "  " + accentStyle.Render("./"+projectName) + "\n"
+	if m.Scaffolder != nil && m.Scaffolder.Mode == scaffold.ModeLocal {
+		if m.Scaffolder.Remote != "" {
+			content += "  " + dimStyle.Render("Remote:") + "    " + accentStyle.Render(m.Scaffolder.Remote) + "\n"
+			content += "  " + dimStyle.Render("git push -u origin "+m.Scaffolder.DefaultBranch()) + " to publish it\n"
+		} else {
+			content += "  " + dimStyle.Render("Local repository only, nothing was pushed") + "\n"
+		}
+	}
+	content += "\n"
 	content += "  " + dimStyle.Render("cd ") + accentStyle.Render(projectName) + dimStyle.Render(" to get started")
 
 	return m.Layout(content, "q quit")
@@ -111,19 +303,69 @@ func (m *Model) ViewScaffolding() string {
 		case "done":
 			s += fmt.Sprintf("  %s  %s\n", greenStyle.Render("✓"), dimStyle.Render(step.Name))
 		case "running":
-			s += fmt.Sprintf("  %s  %s\n", m.Spinner.View(), step.Name)
+			s += fmt.Sprintf("  %s  %s", m.Spinner.View(), step.Name)
+			if p := m.ScaffoldProgress; p.Total > 0 {
+				s += " " + dimStyle.Render(fmt.Sprintf("%d/%d %s", p.Done, p.Total, p.Path))
+			}
+			s += "\n"
 		case "error":
 			s += fmt.Sprintf("  %s  %s\n", redStyle.Render("✗"), redStyle.Render(step.Name))
 		default:
 			s += fmt.Sprintf("  %s  %s\n", dimStyle.Render("○"), dimStyle.Render(step.Name))
 		}
+
+		for _, line := range tailLines(step.Output, 5) {
+			s += "       " + dimStyle.Render(line) + "\n"
+		}
 	}
 
 	if m.ScaffoldError != "" {
 		s += "\n" + redStyle.Render("Error: "+m.ScaffoldError)
 	}
 
+	switch {
+	case m.ScaffoldRollingBack:
+		s += "\n\n" + m.Spinner.View() + " Rolling back..."
+		return m.Layout(s, "q quit")
+
+	case m.ScaffoldRolledBack:
+		s += "\n\n" + dimStyle.Render("Rolled back.")
+		return m.Layout(s, "esc back to form   q quit")
+
+	case m.ScaffoldError != "":
+		s += "\n\n" + "Press " + accentStyle.Render("r") + " to retry from the failed step, " +
+			accentStyle.Render("u") + " to roll back"
+		// only what this run created is removed
+		if m.Scaffolder != nil && m.Scaffolder.CreatedRepo != "" {
+			s += dimStyle.Render(" (deletes "+m.Scaffolder.CreatedRepo+")")
+		}
+		return m.Layout(s, "r retry   u roll back   q quit")
+	}
+
 	s += fmt.Sprintf("\n\n%s", dimStyle.Render(fmt.Sprintf("step %d of %d", m.ScaffoldCurrent+1, len(m.ScaffoldSteps))))
 
 	return m.Layout(s, "q quit")
 }
+
+func (m *Model) resetScaffolding() {
+	m.Scaffolder = nil
+	m.ScaffoldSteps = nil
+	m.ScaffoldCurrent = 0
+	m.ScaffoldError = ""
+	m.ScaffoldRollingBack = false
+	m.ScaffoldRolledBack = false
+}
+
+// tailLines returns the last n non-empty lines of command output.
+func tailLines(output string, n int) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimRight(line, "\r "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
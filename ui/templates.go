@@ -93,9 +93,7 @@ func (m *Model) UpdateTemplates(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(m.Templates) > 0 {
 				idx := m.Table.Cursor()
 				m.SelectedTemplate = m.Templates[idx]
-				m.Screen = screenForm
-				m.FormLoading = true
-				return m, m.fetchTemplateLoadedConfigCmd
+				return m.pickVersion()
 			}
 		
 		case "r":
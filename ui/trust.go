@@ -0,0 +1,72 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kickstartdev/kickstart/internal/auth"
+	"github.com/kickstartdev/kickstart/internal/debug"
+	"github.com/kickstartdev/kickstart/internal/scaffold"
+)
+
+func (m *Model) templateSource() string {
+	return scaffold.SourceFor(m.Token, m.SelectedTemplate).Name()
+}
+
+// startScaffolding begins scaffolding once the form is filled in, asking
+// first whether to run the template's hooks if they haven't been trusted.
+func (m *Model) startScaffolding() (tea.Model, tea.Cmd) {
+	hooks := m.SelectedTemplate.Config.Hooks.PostCreate
+	if m.DryRun || m.Into != "" {
+		// dry runs and pull requests never run hooks, there is no clone to
+		// run them in
+		hooks = nil
+	}
+	if len(hooks) > 0 && !auth.IsTrusted(m.templateSource(), hooks) {
+		m.Screen = screenTrust
+		return m, nil
+	}
+
+	m.RunHooks = len(hooks) > 0
+	m.Screen = screenScaffolding
+	return m, m.scaffoldingCmd(m.startScaffoldingCmd)
+}
+
+func (m *Model) UpdateTrust(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y":
+			hooks := m.SelectedTemplate.Config.Hooks.PostCreate
+			if err := auth.Trust(m.templateSource(), hooks); err != nil {
+				debug.Log("UpdateTrust: failed to save trust: %v", err)
+			}
+			m.RunHooks = true
+			m.Screen = screenScaffolding
+			return m, m.scaffoldingCmd(m.startScaffoldingCmd)
+
+		case "n":
+			m.RunHooks = false
+			m.Screen = screenScaffolding
+			return m, m.scaffoldingCmd(m.startScaffoldingCmd)
+
+		case "esc":
+			m.Screen = screenForm
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Model) ViewTrust() string {
+	s := accentStyle.Render(m.templateSource()) + " wants to run these commands\n"
+	s += dimStyle.Render("in your new project after it is cloned:") + "\n\n"
+
+	for _, command := range m.SelectedTemplate.Config.Hooks.PostCreate {
+		s += "  " + dimStyle.Render("$ ") + command + "\n"
+	}
+
+	s += "\n" + redStyle.Render("Only run commands from templates you trust.") + "\n\n"
+	s += "Press " + accentStyle.Render("y") + " to trust and run them, " + accentStyle.Render("n") + " to skip them"
+
+	return m.Layout(s, "y trust & run   n skip hooks   esc back   q quit")
+}
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kickstartdev/kickstart/internal/github"
+)
+
+// versionsMaxRows is how many versions are listed at once.
+const versionsMaxRows = 12
+
+type versionsLoadedMsg struct {
+	Refs []github.Ref
+}
+
+type versionsErrMsg struct {
+	Err error
+}
+
+func (m *Model) fetchVersionsCmd() tea.Msg {
+	refs, err := github.ListRefs(m.Token, m.SelectedTemplate.Owner, m.SelectedTemplate.Repo)
+	if err != nil {
+		return versionsErrMsg{Err: err}
+	}
+	return versionsLoadedMsg{Refs: refs}
+}
+
+// pickVersion asks which tag, branch or commit of the selected template to
+// use.
+func (m *Model) pickVersion() (tea.Model, tea.Cmd) {
+	m.Screen = screenVersions
+	m.Versions = nil
+	m.VersionsError = ""
+	m.VersionsLoading = true
+	m.VersionEntering = false
+	return m, m.fetchVersionsCmd
+}
+
+// enterVersion asks for a version that isn't listed, usually a commit.
+func (m *Model) enterVersion() {
+	ti := newTextInput()
+	ti.Placeholder = "commit SHA, tag or branch"
+	ti.Focus()
+
+	m.VersionInput = ti
+	m.VersionEntering = true
+}
+
+// useVersion loads the config of the selected template at ref, pinned to
+// sha. An empty sha is resolved when the config is loaded.
+func (m *Model) useVersion(ref string, sha string) (tea.Model, tea.Cmd) {
+	m.SelectedTemplate.Ref = ref
+	m.SelectedTemplate.Revision = sha
+	m.VersionEntering = false
+	m.Screen = screenForm
+	m.FormError = ""
+	m.FormLoading = true
+	return m, m.fetchTemplateLoadedConfigCmd
+}
+
+func (m *Model) UpdateVersions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case versionsLoadedMsg:
+		m.Versions = msg.Refs
+		m.VersionsLoading = false
+		// start on the branch template.yaml asks for, else the default branch
+		m.VersionCursor = 0
+		for i, ref := range m.Versions {
+			if ref.Name == m.SelectedTemplate.Config.Branch {
+				m.VersionCursor = i
+			}
+		}
+		return m, nil
+
+	case versionsErrMsg:
+		m.VersionsError = msg.Err.Error()
+		m.VersionsLoading = false
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.VersionEntering {
+			switch msg.String() {
+			case "enter":
+				if ref := strings.TrimSpace(m.VersionInput.Value()); ref != "" {
+					return m.useVersion(ref, "")
+				}
+				return m, nil
+			case "esc":
+				m.VersionEntering = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.VersionInput, cmd = m.VersionInput.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.VersionCursor > 0 {
+				m.VersionCursor--
+			}
+		case "down", "j":
+			if m.VersionCursor < len(m.Versions)-1 {
+				m.VersionCursor++
+			}
+		case "enter":
+			if len(m.Versions) == 0 {
+				return m, nil
+			}
+			// pin the commit now so config, download and provenance all
+			// see the same version even if the branch moves meanwhile
+			ref := m.Versions[m.VersionCursor]
+			return m.useVersion(ref.Name, ref.SHA)
+		case "c":
+			if !m.VersionsLoading && m.VersionsError == "" {
+				m.enterVersion()
+			}
+		case "r":
+			if m.VersionsError != "" {
+				return m.pickVersion()
+			}
+		case "esc":
+			m.Screen = screenTemplates
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Model) ViewVersions() string {
+	if m.VersionsLoading {
+		return m.Layout("Loading versions .... "+m.Spinner.View(), "q quit")
+	}
+
+	if m.VersionsError != "" {
+		content := redStyle.Render("Error: "+m.VersionsError) + "\n\n"
+		content += "Press " + accentStyle.Render("r") + " to retry"
+		return m.Layout(content, "r retry   esc back   q quit")
+	}
+
+	s := accentStyle.Render(m.SelectedTemplate.Config.Name) + "  " + dimStyle.Render(m.templateSource()) + "\n\n"
+	if m.VersionEntering {
+		s += "Enter a version:\n\n"
+		s += "  " + m.VersionInput.View() + "\n"
+		return m.Layout(s, "enter select   esc cancel")
+	}
+	if len(m.Versions) == 0 {
+		s += dimStyle.Render("No tags or branches found") + "\n"
+		return m.Layout(s, "c enter a commit   esc back   q quit")
+	}
+	s += "Select a version:\n\n"
+
+	start := 0
+	if m.VersionCursor >= versionsMaxRows {
+		start = m.VersionCursor - versionsMaxRows + 1
+	}
+	end := min(start+versionsMaxRows, len(m.Versions))
+
+	for i := start; i < end; i++ {
+		ref := m.Versions[i]
+		cursor := "  "
+		name := fmt.Sprintf("%-30s", ref.Name)
+		if i == m.VersionCursor {
+			cursor = accentStyle.Render(") ")
+			name = accentStyle.Render(name)
+		}
+		kind := ref.Kind
+		if ref.Default {
+			kind = "default branch"
+		}
+		s += fmt.Sprintf("%s%s %s\n", cursor, name, dimStyle.Render(kind+"  "+ref.SHA[:7]))
+	}
+	if len(m.Versions) > versionsMaxRows {
+		s += "\n" + dimStyle.Render(fmt.Sprintf("%d of %d", m.VersionCursor+1, len(m.Versions))) + "\n"
+	}
+
+	return m.Layout(s, "↑/↓ navigate   enter select   c enter a commit   esc back   q quit")
+}